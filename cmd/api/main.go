@@ -26,14 +26,39 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
+	"highperf-api/internal/handlers"
 	"highperf-api/internal/httpserver"
 )
 
 func main() {
 	router := httpserver.NewRouter()
+
+	if acmeCfg, enabled := acmeConfigFromEnv(); enabled {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-stop
+			handlers.SetReady(false)
+			cancel()
+		}()
+
+		handlers.SetReady(true)
+		log.Printf("listening on :443 (acme, domains=%v, staging=%v)", acmeCfg.Domains, acmeCfg.Staging)
+		if err := httpserver.ListenAndServeACME(ctx, acmeCfg, router); err != nil {
+			log.Fatalf("acme serve: %v", err)
+		}
+		return
+	}
+
 	srv := &http.Server{
 		Handler:           router,
 		ReadTimeout:       2 * time.Second,
@@ -49,34 +74,105 @@ func main() {
 		},
 	}
 
-	// SO_REUSEPORT para escalar por proceso (Linux)
-	ln, err := reusePortListen("tcp", ":8080")
-	if err != nil {
-		log.Fatalf("listen: %v", err)
+	// HTTP_LISTENERS escala el accept loop por proceso vía SO_REUSEPORT
+	// (reusePortListen, ver reuseport_unix.go/reuseport_other.go); por
+	// defecto uno por CPU disponible.
+	numListeners := numListenersFromEnv()
+	listeners := make([]net.Listener, 0, numListeners)
+	for i := 0; i < numListeners; i++ {
+		ln, err := reusePortListen("tcp", ":8080")
+		if err != nil {
+			log.Fatalf("listen: %v", err)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	group, groupCtx := errgroup.WithContext(context.Background())
+	for _, ln := range listeners {
+		ln := ln
+		group.Go(func() error {
+			log.Printf("listening on %s", ln.Addr())
+			if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
 	}
 
-	// Arranque del servidor
-	go func() {
-		log.Printf("listening on %s", ln.Addr())
-		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("serve: %v", err)
-		}
-	}()
+	handlers.SetReady(true)
 
 	// Apagado elegante
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	<-stop
+	select {
+	case <-stop:
+	case <-groupCtx.Done():
+		log.Printf("a listener goroutine failed, shutting down")
+	}
+
+	handlers.SetReady(false)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("graceful shutdown error: %v", err)
 	}
+	if err := group.Wait(); err != nil {
+		log.Printf("serve error: %v", err)
+	}
+}
+
+// numListenersFromEnv reads HTTP_LISTENERS (default runtime.GOMAXPROCS(0)).
+// Values below 1 are clamped to 1, and on windows/darwin (no SO_REUSEPORT,
+// see reuseport_other.go) every value is.
+func numListenersFromEnv() int {
+	n := runtime.GOMAXPROCS(0)
+	if v := os.Getenv("HTTP_LISTENERS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			n = parsed
+		} else {
+			log.Printf("invalid HTTP_LISTENERS=%q, using %d", v, n)
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		n = 1
+	}
+	return n
+}
+
+// acmeConfigFromEnv builds an httpserver.TLSConfig from ACME_* environment
+// variables. ACME_DOMAINS is a comma-separated whitelist; enabled is false
+// unless ACME_ENABLED=true and at least one domain is set.
+func acmeConfigFromEnv() (cfg httpserver.TLSConfig, enabled bool) {
+	if os.Getenv("ACME_ENABLED") != "true" {
+		return httpserver.TLSConfig{}, false
+	}
+
+	var domains []string
+	for _, d := range strings.Split(os.Getenv("ACME_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == 0 {
+		log.Printf("ACME_ENABLED=true but ACME_DOMAINS is empty, falling back to plain HTTP")
+		return httpserver.TLSConfig{}, false
+	}
+
+	return httpserver.TLSConfig{
+		Enabled:  true,
+		Domains:  domains,
+		Email:    os.Getenv("ACME_EMAIL"),
+		CacheDir: envOrDefault("ACME_CACHE_DIR", "/var/cache/acme"),
+		Staging:  os.Getenv("ACME_STAGING") == "true",
+	}, true
 }
 
-func reusePortListen(network, address string) (net.Listener, error) {
-	// Usa un lib probado en producción:
-	// github.com/libp2p/go-reuseport o github.com/kavu/go_reuseport
-	// Aquí lo dejamos simple para mantener el snippet autocontenido:
-	return net.Listen(network, address)
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
 }
@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+//go:build windows || darwin
+
+// cmd/api/reuseport_other.go
+package main
+
+import (
+	"log"
+	"net"
+)
+
+// reusePortListen has no SO_REUSEPORT support on this platform: multiple
+// listeners bound to the same port would just fail with "address already
+// in use", so HTTP_LISTENERS is effectively clamped to 1 here (see main).
+func reusePortListen(network, address string) (net.Listener, error) {
+	log.Printf("SO_REUSEPORT is not supported on this platform, falling back to a single plain listener")
+	return net.Listen(network, address)
+}
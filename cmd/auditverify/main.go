@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cmd/auditverify/main.go
+//
+// auditverify walks the audit_log hash chain end to end and reports the
+// first row (if any) whose hash no longer matches its predecessor - i.e.
+// a row that was edited, deleted, or inserted out of band after the fact.
+// Meant to run periodically out of cron/CI against a database that isn't
+// being written to concurrently, not as part of request handling.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"highperf-api/internal/audit"
+	"highperf-api/internal/config"
+	"highperf-api/internal/database"
+	"highperf-api/internal/logger"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	defer cfg.Close()
+
+	appLog := logger.New(cfg.Logger)
+
+	db, err := database.Connect(cfg.Database, appLog)
+	if err != nil {
+		appLog.Error("connect database", "error", err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	brokenAt, err := audit.VerifyChain(context.Background(), db)
+	if err != nil {
+		appLog.Error("verify audit chain", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if brokenAt != 0 {
+		appLog.Error("audit chain verification failed", "broken_at_id", brokenAt)
+		os.Exit(1)
+	}
+
+	appLog.Info("audit chain verified clean")
+}
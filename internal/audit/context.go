@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/audit/context.go
+package audit
+
+import "context"
+
+type contextKey int
+
+const (
+	actorContextKey contextKey = iota
+	requestIDContextKey
+)
+
+// WithActor attaches the actor performing the current request to ctx, so
+// anything recording an audit entry further down the call chain - a
+// decorated repository, a handler - doesn't need the actor threaded
+// through every function signature. httpserver's withAudit middleware
+// sets this once per request.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext retrieves the Actor WithActor attached, or the zero
+// Actor if none was set (e.g. an unauthenticated request, or a call made
+// outside of an HTTP request such as a migration script).
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorContextKey).(Actor)
+	return actor
+}
+
+// WithRequestID attaches the current request's id to ctx for Entry.RequestID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext retrieves the request id WithRequestID attached, or
+// "" if none was set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
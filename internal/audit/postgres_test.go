@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+// postgresRoundTrip simulates what a Postgres timestamp/timestamptz column
+// does to a time.Time: it keeps microsecond precision and drops anything
+// finer, the same lossy round trip VerifyChain sees when it re-reads a row.
+func postgresRoundTrip(t time.Time) time.Time {
+	return t.Truncate(time.Microsecond)
+}
+
+// TestChainHashSurvivesPostgresRoundTrip is a regression test for hashing
+// CreatedAt at nanosecond precision: Record computes the hash once with an
+// in-memory time.Time (full nanosecond precision), but VerifyChain
+// recomputes it later from a value that already lost its sub-microsecond
+// digits in storage. If chainHash doesn't normalize to the same precision
+// both times, every row whose nanosecond component isn't an exact multiple
+// of 1000 fails verification despite never having been tampered with.
+func TestChainHashSurvivesPostgresRoundTrip(t *testing.T) {
+	entry := Entry{
+		Actor:        Actor{UserID: 1, IP: "127.0.0.1", UserAgent: "test"},
+		Action:       "user.create",
+		ResourceType: "user",
+		ResourceID:   "42",
+		RequestID:    "req-1",
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC), // not a multiple of 1000ns
+	}
+
+	writeHash, err := chainHash("", entry)
+	if err != nil {
+		t.Fatalf("chainHash (write path) failed: %v", err)
+	}
+
+	roundTripped := entry
+	roundTripped.CreatedAt = postgresRoundTrip(entry.CreatedAt)
+
+	verifyHash, err := chainHash("", roundTripped)
+	if err != nil {
+		t.Fatalf("chainHash (verify path) failed: %v", err)
+	}
+
+	if writeHash != verifyHash {
+		t.Errorf("hash changed after a Postgres-precision round trip: write=%s verify=%s", writeHash, verifyHash)
+	}
+}
+
+func TestChainHashDeterministic(t *testing.T) {
+	entry := Entry{
+		Actor:        Actor{UserID: 7},
+		Action:       "user.update",
+		ResourceType: "user",
+		ResourceID:   "7",
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	h1, err := chainHash("prev", entry)
+	if err != nil {
+		t.Fatalf("chainHash failed: %v", err)
+	}
+	h2, err := chainHash("prev", entry)
+	if err != nil {
+		t.Fatalf("chainHash failed: %v", err)
+	}
+	if h1 != h2 {
+		t.Errorf("chainHash isn't deterministic: %s != %s", h1, h2)
+	}
+
+	h3, _ := chainHash("different-prev", entry)
+	if h3 == h1 {
+		t.Error("expected a different prevHash to change the resulting hash")
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/audit/user_repository.go
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"highperf-api/internal/models"
+	"highperf-api/internal/repository"
+)
+
+// auditedUserRepository decorates a repository.UserRepository so every
+// Create, Update, and Delete is also recorded to an Auditor, without the
+// underlying implementation knowing auditing exists.
+type auditedUserRepository struct {
+	repository.UserRepository
+	auditor Auditor
+}
+
+// NewAuditedUserRepository wraps inner so Create/Update/Delete each write
+// an audit entry via auditor after the underlying call succeeds. Every other
+// method (GetByID, GetByEmail, List, UpsertFromIdentity) passes straight
+// through to the embedded inner and is not audited - including
+// UpsertFromIdentity, which can create a user, and any method added to
+// UserRepository later.
+func NewAuditedUserRepository(inner repository.UserRepository, auditor Auditor) repository.UserRepository {
+	return &auditedUserRepository{UserRepository: inner, auditor: auditor}
+}
+
+func (r *auditedUserRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	created, err := r.UserRepository.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, _ := json.Marshal(map[string]interface{}{"new": created.ToResponse()})
+	_ = r.auditor.Record(ctx, r.entry(ctx, "user.create", created.ID, diff))
+	return created, nil
+}
+
+func (r *auditedUserRepository) Update(ctx context.Context, user *models.User) (*models.User, error) {
+	before, _ := r.UserRepository.GetByID(ctx, user.ID)
+
+	updated, err := r.UserRepository.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, _ := json.Marshal(map[string]interface{}{"old": responseOrNil(before), "new": updated.ToResponse()})
+	_ = r.auditor.Record(ctx, r.entry(ctx, "user.update", updated.ID, diff))
+	return updated, nil
+}
+
+func (r *auditedUserRepository) Delete(ctx context.Context, id int64) error {
+	before, _ := r.UserRepository.GetByID(ctx, id)
+
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	diff, _ := json.Marshal(map[string]interface{}{"old": responseOrNil(before)})
+	_ = r.auditor.Record(ctx, r.entry(ctx, "user.delete", id, diff))
+	return nil
+}
+
+func (r *auditedUserRepository) entry(ctx context.Context, action string, resourceID int64, diff json.RawMessage) Entry {
+	return Entry{
+		Actor:        ActorFromContext(ctx),
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   strconv.FormatInt(resourceID, 10),
+		Diff:         diff,
+		RequestID:    RequestIDFromContext(ctx),
+	}
+}
+
+func responseOrNil(u *models.User) *models.UserResponse {
+	if u == nil {
+		return nil
+	}
+	return u.ToResponse()
+}
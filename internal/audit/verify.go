@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/audit/verify.go
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"highperf-api/internal/database"
+)
+
+// VerifyChain walks the audit_log table in id order, recomputing each
+// row's hash from (prev_hash || canonical JSON) and checking it against
+// the stored hash and the previous row's hash. It returns the id of the
+// first row that breaks the chain, or 0 if the whole log verifies clean.
+//
+// It's meant to run out-of-band (see cmd/auditverify) against a table
+// that isn't being written to concurrently - VerifyChain takes no lock,
+// so a Record racing with it could produce a false positive.
+func VerifyChain(ctx context.Context, db *database.DB) (brokenAt int64, err error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, prev_hash, hash, actor_user_id, actor_ip, actor_user_agent,
+			action, resource_type, resource_id, diff, request_id, created_at
+		FROM audit_log
+		ORDER BY id ASC`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer rows.Close()
+
+	expectedPrev := ""
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(
+			&e.ID, &e.PrevHash, &e.Hash, &e.Actor.UserID, &e.Actor.IP, &e.Actor.UserAgent,
+			&e.Action, &e.ResourceType, &e.ResourceID, &e.Diff, &e.RequestID, &e.CreatedAt,
+		); err != nil {
+			return 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		if e.PrevHash != expectedPrev {
+			return e.ID, nil
+		}
+		hash, err := chainHash(e.PrevHash, e)
+		if err != nil {
+			return 0, err
+		}
+		if hash != e.Hash {
+			return e.ID, nil
+		}
+		expectedPrev = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating audit log: %w", err)
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package connectors lets operators wire third-party identity providers
+// (GitHub, Google, generic OIDC) alongside the existing password login flow,
+// similar in shape to dex's connector model.
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// ExternalIdentity is what a Connector resolves a successful callback to.
+type ExternalIdentity struct {
+	Provider string
+	Subject  string // provider-stable user id, e.g. GitHub's numeric id
+	Email    string
+	Name     string
+}
+
+// Connector is a single external identity provider wired into the login
+// flow. LoginURL starts the provider's authorization flow; HandleCallback
+// exchanges the authorization response for an ExternalIdentity.
+type Connector interface {
+	ID() string
+	Type() string
+	LoginURL(state string) (string, error)
+	HandleCallback(ctx context.Context, code, state string) (*ExternalIdentity, error)
+}
+
+// IdentityHandler is invoked once a Connector has resolved a callback to an
+// ExternalIdentity, so the caller can upsert a local user and mint tokens.
+type IdentityHandler func(w http.ResponseWriter, r *http.Request, identity *ExternalIdentity)
+
+// Registry mounts one or more Connectors on an httprouter.Router at
+// /auth/connectors/{connector_id}/login and
+// /auth/connectors/{connector_id}/callback. The extra "connectors" segment
+// (rather than bare /auth/{connector_id}/...) keeps this from conflicting
+// with the static /auth/oidc/... routes already registered by
+// httpserver.newOIDCLoginHandler - httprouter's tree can't have both a
+// static and a wildcard child at the same position.
+type Registry struct {
+	connectors map[string]Connector
+	states     StateStore
+	onIdentity IdentityHandler
+}
+
+// NewRegistry creates a Registry that calls onIdentity after a successful
+// callback. onIdentity is responsible for upserting the local user and
+// responding to the request (e.g. minting a TokenPair). It defaults to an
+// InMemoryStateStore; call SetStateStore to switch to a RedisStateStore
+// once more than one instance is running behind a load balancer.
+func NewRegistry(onIdentity IdentityHandler) *Registry {
+	return &Registry{
+		connectors: make(map[string]Connector),
+		states:     NewInMemoryStateStore(),
+		onIdentity: onIdentity,
+	}
+}
+
+// SetStateStore swaps in store (e.g. a RedisStateStore) for issuing and
+// consuming login `state` values. Without this, state is only valid on the
+// instance that issued it - fine for a single instance, not for one behind
+// a load balancer that may route the callback to a different instance.
+func (reg *Registry) SetStateStore(store StateStore) {
+	reg.states = store
+}
+
+// Register adds a connector, keyed by its ID().
+func (reg *Registry) Register(c Connector) {
+	reg.connectors[c.ID()] = c
+}
+
+// Mount wires /auth/connectors/:connector/login and
+// /auth/connectors/:connector/callback onto r.
+func (reg *Registry) Mount(r *httprouter.Router) {
+	r.GET("/auth/connectors/:connector/login", reg.handleLogin)
+	r.GET("/auth/connectors/:connector/callback", reg.handleCallback)
+}
+
+func (reg *Registry) handleLogin(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	c, ok := reg.connectors[ps.ByName("connector")]
+	if !ok {
+		http.Error(w, "unknown connector", http.StatusNotFound)
+		return
+	}
+
+	state, err := reg.states.Issue(c.ID())
+	if err != nil {
+		http.Error(w, "failed to issue state", http.StatusInternalServerError)
+		return
+	}
+
+	loginURL, err := c.LoginURL(state)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to build login url: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+func (reg *Registry) handleCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	c, ok := reg.connectors[ps.ByName("connector")]
+	if !ok {
+		http.Error(w, "unknown connector", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	state := q.Get("state")
+	code := q.Get("code")
+
+	if !reg.states.Consume(c.ID(), state) {
+		http.Error(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := c.HandleCallback(r.Context(), code, state)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("callback failed: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	reg.onIdentity(w, r, identity)
+}
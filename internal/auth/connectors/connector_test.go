@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// fakeConnector is a minimal Connector for exercising Registry without any
+// network calls.
+type fakeConnector struct {
+	id       string
+	identity *ExternalIdentity
+	err      error
+}
+
+func (c *fakeConnector) ID() string   { return c.id }
+func (c *fakeConnector) Type() string { return "fake" }
+
+func (c *fakeConnector) LoginURL(state string) (string, error) {
+	return "https://provider.example.com/authorize?state=" + state, nil
+}
+
+func (c *fakeConnector) HandleCallback(_ context.Context, _, _ string) (*ExternalIdentity, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.identity, nil
+}
+
+func newTestRegistry(c Connector) (*Registry, *ExternalIdentity) {
+	var got *ExternalIdentity
+	reg := NewRegistry(func(w http.ResponseWriter, r *http.Request, identity *ExternalIdentity) {
+		got = identity
+		w.WriteHeader(http.StatusOK)
+	})
+	reg.Register(c)
+	return reg, got
+}
+
+func TestRegistryLoginIssuesStateAndRedirects(t *testing.T) {
+	reg, _ := newTestRegistry(&fakeConnector{id: "fake"})
+	router := httprouter.New()
+	reg.Mount(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/connectors/fake/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc == "" {
+		t.Error("expected a Location header pointing at the provider")
+	}
+}
+
+func TestRegistryLoginUnknownConnector(t *testing.T) {
+	reg, _ := newTestRegistry(&fakeConnector{id: "fake"})
+	router := httprouter.New()
+	reg.Mount(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/connectors/nope/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unregistered connector, got %d", w.Code)
+	}
+}
+
+func TestRegistryCallbackRejectsInvalidState(t *testing.T) {
+	c := &fakeConnector{id: "fake", identity: &ExternalIdentity{Provider: "fake", Subject: "1"}}
+	reg, _ := newTestRegistry(c)
+	router := httprouter.New()
+	reg.Mount(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/connectors/fake/callback?code=abc&state=never-issued", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown state, got %d", w.Code)
+	}
+}
+
+func TestRegistryCallbackConsumesStateOnce(t *testing.T) {
+	c := &fakeConnector{id: "fake", identity: &ExternalIdentity{Provider: "fake", Subject: "1"}}
+	reg, _ := newTestRegistry(c)
+	router := httprouter.New()
+	reg.Mount(router)
+
+	state, err := reg.states.Issue("fake")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/connectors/fake/callback?code=abc&state="+state, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the first callback to succeed, got %d", w.Code)
+	}
+
+	// Replaying the same state (e.g. the provider redirect page reloaded)
+	// must fail now that it's been consumed.
+	req2 := httptest.NewRequest(http.MethodGet, "/auth/connectors/fake/callback?code=abc&state="+state, nil)
+	w2 := httptest.NewRecorder()
+	router.ServeHTTP(w2, req2)
+	if w2.Code != http.StatusBadRequest {
+		t.Errorf("expected replaying a consumed state to fail, got %d", w2.Code)
+	}
+}
+
+func TestInMemoryStateStoreRejectsWrongConnector(t *testing.T) {
+	s := NewInMemoryStateStore()
+
+	state, err := s.Issue("github")
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if s.Consume("google", state) {
+		t.Error("expected Consume to reject a state issued for a different connector")
+	}
+	// The mismatched attempt above must still have consumed the entry, so a
+	// correct second attempt doesn't get to retry it either.
+	if s.Consume("github", state) {
+		t.Error("expected Consume to fail on a state already consumed by the mismatched attempt")
+	}
+}
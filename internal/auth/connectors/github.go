@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	githubAuthorizeURL  = "https://github.com/login/oauth/authorize"
+	githubTokenURL      = "https://github.com/login/oauth/access_token"
+	githubUserURL       = "https://api.github.com/user"
+	githubUserEmailsURL = "https://api.github.com/user/emails"
+	githubScope         = "read:user user:email"
+)
+
+// GitHubConfig holds the OAuth2 app credentials registered with GitHub.
+type GitHubConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// GitHubConnector authenticates users against GitHub's OAuth2 flow.
+type GitHubConnector struct {
+	cfg    GitHubConfig
+	client *http.Client
+}
+
+// NewGitHubConnector creates a Connector for GitHub OAuth2 login.
+func NewGitHubConnector(cfg GitHubConfig) *GitHubConnector {
+	return &GitHubConnector{cfg: cfg, client: http.DefaultClient}
+}
+
+func (g *GitHubConnector) ID() string   { return "github" }
+func (g *GitHubConnector) Type() string { return "github" }
+
+func (g *GitHubConnector) LoginURL(state string) (string, error) {
+	q := url.Values{
+		"client_id":    {g.cfg.ClientID},
+		"redirect_uri": {g.cfg.RedirectURL},
+		"scope":        {githubScope},
+		"state":        {state},
+	}
+	return githubAuthorizeURL + "?" + q.Encode(), nil
+}
+
+func (g *GitHubConnector) HandleCallback(ctx context.Context, code, state string) (*ExternalIdentity, error) {
+	accessToken, err := g.exchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := g.fetchUser(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Email == "" {
+		email, err := g.fetchPrimaryEmail(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		user.Email = email
+	}
+
+	return &ExternalIdentity{
+		Provider: g.ID(),
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Email:    user.Email,
+		Name:     user.Name,
+	}, nil
+}
+
+func (g *GitHubConnector) exchangeCode(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"client_id":     {g.cfg.ClientID},
+		"client_secret": {g.cfg.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {g.cfg.RedirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, githubTokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.URL.RawQuery = form.Encode()
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.Error != "" {
+		return "", fmt.Errorf("github oauth error: %s", tokenResp.Error)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("github oauth returned no access token")
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (g *GitHubConnector) fetchUser(ctx context.Context, accessToken string) (*githubUser, error) {
+	var user githubUser
+	if err := g.getJSON(ctx, githubUserURL, accessToken, &user); err != nil {
+		return nil, fmt.Errorf("failed to fetch github user: %w", err)
+	}
+	return &user, nil
+}
+
+func (g *GitHubConnector) fetchPrimaryEmail(ctx context.Context, accessToken string) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.getJSON(ctx, githubUserEmailsURL, accessToken, &emails); err != nil {
+		return "", fmt.Errorf("failed to fetch github emails: %w", err)
+	}
+
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+	return "", fmt.Errorf("no verified primary email on github account")
+}
+
+func (g *GitHubConnector) getJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status from %s: %d", endpoint, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/auth/connectors/state.go
+package connectors
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const stateTTL = 5 * time.Minute
+
+// StateStore issues and consumes single-use OAuth2 `state` values so the
+// login/callback round trip is protected against CSRF and replay: a state
+// is only ever valid once, for the connector that issued it, within a short
+// TTL. InMemoryStateStore is the default for single-instance deployments;
+// behind a load balancer with more than one instance, use RedisStateStore
+// instead (via Registry.SetStateStore) so a state issued by one instance is
+// still consumable when the provider's redirect lands on another.
+type StateStore interface {
+	Issue(connectorID string) (string, error)
+	Consume(connectorID, state string) bool
+}
+
+// InMemoryStateStore is a process-local StateStore backed by a TTL map.
+type InMemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]stateEntry
+}
+
+type stateEntry struct {
+	connectorID string
+	expiresAt   time.Time
+}
+
+// NewInMemoryStateStore creates an empty, in-memory state store.
+func NewInMemoryStateStore() *InMemoryStateStore {
+	return &InMemoryStateStore{states: make(map[string]stateEntry)}
+}
+
+// Issue mints a new random state value scoped to connectorID.
+func (s *InMemoryStateStore) Issue(connectorID string) (string, error) {
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.gcLocked()
+	s.states[state] = stateEntry{connectorID: connectorID, expiresAt: time.Now().Add(stateTTL)}
+	s.mu.Unlock()
+
+	return state, nil
+}
+
+// Consume validates that state was issued for connectorID, has not expired,
+// and has not been consumed before, removing it in the process so it can
+// never be replayed.
+func (s *InMemoryStateStore) Consume(connectorID, state string) bool {
+	if state == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.states[state]
+	if !ok {
+		return false
+	}
+	delete(s.states, state)
+
+	if entry.connectorID != connectorID {
+		return false
+	}
+	return time.Now().Before(entry.expiresAt)
+}
+
+// gcLocked drops expired states. Callers must hold s.mu.
+func (s *InMemoryStateStore) gcLocked() {
+	now := time.Now()
+	for state, entry := range s.states {
+		if now.After(entry.expiresAt) {
+			delete(s.states, state)
+		}
+	}
+}
+
+// RedisStateStore is a StateStore backed by Redis, for deployments that run
+// more than one API instance behind a load balancer: the state issued by
+// whichever instance handled the login redirect is consumable by whichever
+// instance handles the provider's callback.
+type RedisStateStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStateStore creates a RedisStateStore using client, namespacing
+// keys under "oauth_state:" so they don't collide with other uses of the
+// same Redis database.
+func NewRedisStateStore(client *redis.Client) *RedisStateStore {
+	return &RedisStateStore{client: client, prefix: "oauth_state:"}
+}
+
+// Issue mints a new random state value and records connectorID against it
+// in Redis with stateTTL.
+func (s *RedisStateStore) Issue(connectorID string) (string, error) {
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.client.Set(context.Background(), s.prefix+state, connectorID, stateTTL).Err(); err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return state, nil
+}
+
+// Consume atomically fetches and deletes the stored connectorID for state,
+// so the same state can never be consumed twice even if two instances race
+// on the same callback.
+func (s *RedisStateStore) Consume(connectorID, state string) bool {
+	if state == "" {
+		return false
+	}
+
+	stored, err := s.client.GetDel(context.Background(), s.prefix+state).Result()
+	if err != nil {
+		// Covers both redis.Nil (unknown/already-consumed/expired state) and
+		// a Redis error - fail closed rather than risk accepting a state we
+		// couldn't actually verify.
+		return false
+	}
+	return stored == connectorID
+}
+
+// randomState generates the random, URL-safe value Issue hands back to the
+// caller as the `state` query parameter.
+func randomState() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
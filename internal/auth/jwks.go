@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/auth/jwks.go
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is a single entry of RFC 7517 JSON Web Key Set, restricted to the
+// fields our supported algorithms (RS256, ES256/384/512, EdDSA) need.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC / OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is an RFC 7517 JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeySet is implemented by key sets that can enumerate their public
+// keys, which the degenerate HS256 staticKeySet intentionally does not
+// support since a shared secret must never be exposed.
+type PublicKeySet interface {
+	publicKeys() []*namedKey
+}
+
+// JWKSHandler serves the public half of every active signing key as a JWKS
+// document at GET /.well-known/jwks.json. It only works for KeySet
+// implementations that expose publicKeys (i.e. asymmetric rotation sets);
+// the legacy HS256 static key set has nothing to publish.
+func JWKSHandler(keys KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pks, ok := keys.(PublicKeySet)
+		if !ok {
+			http.Error(w, "jwks not available for this key set", http.StatusNotFound)
+			return
+		}
+
+		jwks := JWKS{Keys: make([]JWK, 0, len(pks.publicKeys()))}
+		for _, k := range pks.publicKeys() {
+			jwk, err := toJWK(k)
+			if err != nil {
+				http.Error(w, "failed to encode jwks", http.StatusInternalServerError)
+				return
+			}
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(jwks)
+	}
+}
+
+func toJWK(k *namedKey) (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch pub := k.priv.Public().(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: algName(k.alg),
+			Kid: k.kid,
+			N:   b64(pub.N.Bytes()),
+			E:   b64(big2bytes(pub.E)),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: algName(k.alg),
+			Kid: k.kid,
+			Crv: pub.Curve.Params().Name,
+			X:   b64(padBytes(pub.X.Bytes(), size)),
+			Y:   b64(padBytes(pub.Y.Bytes(), size)),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: algName(k.alg),
+			Kid: k.kid,
+			Crv: "Ed25519",
+			X:   b64(pub),
+		}, nil
+	default:
+		return JWK{}, errUnsupportedPublicKey
+	}
+}
+
+var errUnsupportedPublicKey = jwksError("unsupported public key type for JWKS")
+
+type jwksError string
+
+func (e jwksError) Error() string { return string(e) }
+
+func algName(alg jwt.SigningMethod) string {
+	return alg.Alg()
+}
+
+// big2bytes encodes a small positive int (RSA's E, typically 65537) as the
+// minimal big-endian byte slice JWK expects.
+func big2bytes(e int) []byte {
+	if e == 0 {
+		return []byte{0}
+	}
+	var b []byte
+	for e > 0 {
+		b = append([]byte{byte(e & 0xff)}, b...)
+		e >>= 8
+	}
+	return b
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
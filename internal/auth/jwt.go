@@ -35,20 +35,42 @@ type Claims struct {
 
 // JWTService handles JWT operations
 type JWTService struct {
-	secret        []byte
+	keys          KeySet
 	tokenExpiry   time.Duration
 	refreshExpiry time.Duration
+	replay        ReplayStore
 }
 
-// NewJWTService creates a new JWT service
+// NewJWTService creates a new JWT service backed by the legacy shared HS256
+// secret. Use NewJWTServiceWithKeySet for asymmetric signing and rotation.
 func NewJWTService(cfg config.AuthConfig) *JWTService {
+	return NewJWTServiceWithKeySet(newStaticKeySet([]byte(cfg.JWTSecret)), cfg)
+}
+
+// NewJWTServiceWithKeySet creates a JWT service that signs with whatever key
+// keys.Current() returns and verifies via keys.Lookup, so callers can plug
+// in asymmetric keys (RS256/ES256/EdDSA) with kid-based rotation.
+func NewJWTServiceWithKeySet(keys KeySet, cfg config.AuthConfig) *JWTService {
 	return &JWTService{
-		secret:        []byte(cfg.JWTSecret),
+		keys:          keys,
 		tokenExpiry:   cfg.TokenExpiry,
 		refreshExpiry: cfg.RefreshExpiry,
 	}
 }
 
+// KeySet exposes the underlying key set, e.g. to mount JWKSHandler.
+func (j *JWTService) KeySet() KeySet {
+	return j.keys
+}
+
+// SetReplayStore wires a ReplayStore used to enforce one-shot use of
+// refresh tokens and, when ValidateOptions.RequireJTI is set, of any other
+// token. Without a replay store, RefreshToken accepts a refresh token
+// repeatedly until it expires.
+func (j *JWTService) SetReplayStore(store ReplayStore) {
+	j.replay = store
+}
+
 // TokenPair represents access and refresh tokens
 type TokenPair struct {
 	AccessToken  string    `json:"access_token"`
@@ -60,6 +82,13 @@ type TokenPair struct {
 // GenerateTokenPair generates access and refresh tokens
 func (j *JWTService) GenerateTokenPair(userID int64, email string) (*TokenPair, error) {
 	now := time.Now()
+	kid, priv, alg := j.keys.Current()
+	signingKey := signingKeyFor(priv)
+
+	accessJTI, err := GenerateSecureToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token jti: %w", err)
+	}
 
 	// Generate access token
 	accessClaims := &Claims{
@@ -71,15 +100,22 @@ func (j *JWTService) GenerateTokenPair(userID int64, email string) (*TokenPair,
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "highperf-api",
 			Subject:   fmt.Sprintf("user:%d", userID),
+			ID:        accessJTI,
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(j.secret)
+	accessToken := jwt.NewWithClaims(alg, accessClaims)
+	accessToken.Header["kid"] = kid
+	accessTokenString, err := accessToken.SignedString(signingKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign access token: %w", err)
 	}
 
+	refreshJTI, err := GenerateSecureToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token jti: %w", err)
+	}
+
 	// Generate refresh token
 	refreshClaims := &Claims{
 		UserID: userID,
@@ -90,11 +126,13 @@ func (j *JWTService) GenerateTokenPair(userID int64, email string) (*TokenPair,
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "highperf-api",
 			Subject:   fmt.Sprintf("refresh:%d", userID),
+			ID:        refreshJTI,
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(j.secret)
+	refreshToken := jwt.NewWithClaims(alg, refreshClaims)
+	refreshToken.Header["kid"] = kid
+	refreshTokenString, err := refreshToken.SignedString(signingKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign refresh token: %w", err)
 	}
@@ -107,14 +145,43 @@ func (j *JWTService) GenerateTokenPair(userID int64, email string) (*TokenPair,
 	}, nil
 }
 
-// ValidateToken validates a JWT token and returns claims
+// ValidateOptions tightens ValidateTokenWithOptions beyond the standard exp
+// check. It is meant for service-to-service and refresh flows where a
+// stricter issuance window matters more than on a normal user-facing
+// access token.
+type ValidateOptions struct {
+	// MaxAge rejects tokens whose iat is older than MaxAge, if non-zero.
+	MaxAge time.Duration
+	// Leeway is how far into the future iat/nbf are allowed to be (and how
+	// far past exp a token is still accepted), to absorb clock skew between
+	// issuer and verifier.
+	Leeway time.Duration
+	// RequireJTI rejects tokens with no jti and, when a ReplayStore is
+	// configured, consults it to reject a jti that was already seen.
+	RequireJTI bool
+}
+
+// ValidateToken validates a JWT token and returns claims, with no
+// additional freshness window or replay protection. See
+// ValidateTokenWithOptions for refresh and admin flows that need both.
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	return j.ValidateTokenWithOptions(tokenString, ValidateOptions{})
+}
+
+// ValidateTokenWithOptions validates a JWT token and, per opts, additionally
+// enforces an iat freshness window and/or jti-based replay protection.
+func (j *JWTService) ValidateTokenWithOptions(tokenString string, opts ValidateOptions) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		pub, alg, ok := j.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %q", kid)
+		}
+		if token.Method.Alg() != alg.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return j.secret, nil
-	})
+		return pub, nil
+	}, jwt.WithLeeway(opts.Leeway))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -125,10 +192,51 @@ func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.ErrUnauthorized.WithMessage("Invalid token")
 	}
 
+	if err := j.checkFreshness(claims, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.RequireJTI {
+		if claims.ID == "" {
+			return nil, errors.ErrUnauthorized.WithMessage("Token is missing jti")
+		}
+		if j.replay != nil && j.replay.SeenBefore(claims.ID, claims.ExpiresAt.Time) {
+			return nil, errors.ErrUnauthorized.WithMessage("Token has already been used")
+		}
+	}
+
 	return claims, nil
 }
 
-// RefreshToken generates a new access token from a refresh token
+// checkFreshness enforces opts.MaxAge/opts.Leeway against iat/nbf, similar
+// to how the go-ethereum engine API bounds its RPC JWT to a tight ±5s
+// window.
+func (j *JWTService) checkFreshness(claims *Claims, opts ValidateOptions) error {
+	if opts.MaxAge == 0 && opts.Leeway == 0 {
+		return nil
+	}
+
+	if claims.IssuedAt == nil {
+		return errors.ErrUnauthorized.WithMessage("Token is missing iat")
+	}
+
+	now := time.Now()
+	iat := claims.IssuedAt.Time
+
+	if iat.After(now.Add(opts.Leeway)) {
+		return errors.ErrUnauthorized.WithMessage("Token issued in the future")
+	}
+	if opts.MaxAge > 0 && now.After(iat.Add(opts.MaxAge)) {
+		return errors.ErrUnauthorized.WithMessage("Token is too old")
+	}
+
+	return nil
+}
+
+// RefreshToken generates a new access token from a refresh token. When a
+// ReplayStore is configured, the presented refresh jti is invalidated as
+// part of minting the new pair, so a stolen refresh token can be used at
+// most once instead of being reusable until exp.
 func (j *JWTService) RefreshToken(refreshTokenString string) (*TokenPair, error) {
 	claims, err := j.ValidateToken(refreshTokenString)
 	if err != nil {
@@ -140,10 +248,27 @@ func (j *JWTService) RefreshToken(refreshTokenString string) (*TokenPair, error)
 		return nil, errors.ErrUnauthorized.WithMessage("Not a refresh token")
 	}
 
+	if j.replay != nil && claims.ID != "" {
+		if j.replay.SeenBefore(claims.ID, claims.ExpiresAt.Time) {
+			return nil, errors.ErrUnauthorized.WithMessage("Refresh token has already been used")
+		}
+	}
+
 	// Generate new token pair
 	return j.GenerateTokenPair(claims.UserID, claims.Email)
 }
 
+// signingKeyFor unwraps the degenerate HS256 secret back into the raw
+// []byte form jwt.SigningMethodHS256.Sign expects; every other signing
+// method accepts the crypto.Signer (concrete *rsa.PrivateKey,
+// *ecdsa.PrivateKey or ed25519.PrivateKey) directly.
+func signingKeyFor(priv interface{}) interface{} {
+	if hs, ok := priv.(hmacSecret); ok {
+		return []byte(hs)
+	}
+	return priv
+}
+
 // ExtractTokenFromBearer extracts token from "Bearer <token>" format
 func ExtractTokenFromBearer(authHeader string) (string, error) {
 	const bearerPrefix = "Bearer "
@@ -151,4 +276,4 @@ func ExtractTokenFromBearer(authHeader string) (string, error) {
 		return "", errors.ErrUnauthorized.WithMessage("Invalid authorization header format")
 	}
 	return authHeader[len(bearerPrefix):], nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package jwt issues and verifies signed session/access tokens with
+// github.com/go-jose/go-jose/v3, keeping a small ring of signing keys so
+// they can be rotated without downtime. This is a separate, go-jose-backed
+// track from auth.JWTService (which uses golang-jwt/jwt/v5 and a
+// KeySet/FileKeySet rotation model) - pick whichever fits the deployment.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+)
+
+// signingKey is one entry in a KeyManager's ring.
+type signingKey struct {
+	kid       string
+	priv      crypto.Signer
+	alg       jose.SignatureAlgorithm
+	createdAt time.Time
+	retiredAt time.Time // zero while the key is active
+}
+
+// KeyManager holds a small ring of signing keys, one of them active. Rotate
+// generates a new key, promotes it to active, and drops any retired key
+// whose overlap window (counted from its retirement) has passed, so tokens
+// signed just before a rotation still verify for a while.
+type KeyManager struct {
+	mu      sync.RWMutex
+	keys    []*signingKey // newest first; keys[0] is active
+	overlap time.Duration
+}
+
+// NewKeyManager creates a KeyManager with one freshly-generated Ed25519 key
+// active. overlap is how long a retired key's public half stays published
+// and verifiable after a newer key is promoted; a key past its overlap is
+// dropped at the next Rotate.
+func NewKeyManager(overlap time.Duration) (*KeyManager, error) {
+	km := &KeyManager{overlap: overlap}
+	if err := km.Rotate(); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// Rotate generates a new Ed25519 key, promotes it to active (retiring the
+// previous one), and drops any key retired longer ago than the overlap
+// window.
+func (km *KeyManager) Rotate() error {
+	kid, err := randomKid()
+	if err != nil {
+		return err
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	now := time.Now()
+	if len(km.keys) > 0 {
+		km.keys[0].retiredAt = now
+	}
+	km.keys = append([]*signingKey{{kid: kid, priv: priv, alg: jose.EdDSA, createdAt: now}}, km.keys...)
+
+	kept := km.keys[:0]
+	for _, k := range km.keys {
+		if k.retiredAt.IsZero() || now.Sub(k.retiredAt) < km.overlap {
+			kept = append(kept, k)
+		}
+	}
+	km.keys = kept
+
+	return nil
+}
+
+// active returns the key currently used to sign new tokens.
+func (km *KeyManager) active() *signingKey {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.keys[0]
+}
+
+// lookup resolves the public key for kid, including retired-but-not-yet-
+// expired keys, so tokens signed a moment before a rotation still verify.
+func (km *KeyManager) lookup(kid string) (crypto.PublicKey, jose.SignatureAlgorithm, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	for _, k := range km.keys {
+		if k.kid == kid {
+			return k.priv.Public(), k.alg, true
+		}
+	}
+	return nil, "", false
+}
+
+// ServeJWKS publishes every key the manager currently holds (active plus
+// any still inside their overlap window) as a JSON Web Key Set.
+func (km *KeyManager) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	km.mu.RLock()
+	set := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(km.keys))}
+	for _, k := range km.keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       k.priv.Public(),
+			KeyID:     k.kid,
+			Algorithm: string(k.alg),
+			Use:       "sig",
+		})
+	}
+	km.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = writeJSON(w, set)
+}
+
+func randomKid() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate kid: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
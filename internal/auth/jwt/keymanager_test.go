@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+)
+
+func TestKeyManagerIssueAndVerifyRoundTrip(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	signed, err := km.IssueToken(Claims{Roles: []string{"admin"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	claims, err := km.ParseAndVerify(context.Background(), signed, "", "")
+	if err != nil {
+		t.Fatalf("ParseAndVerify failed: %v", err)
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != "admin" {
+		t.Errorf("expected roles [admin], got %v", claims.Roles)
+	}
+}
+
+func TestKeyManagerParseAndVerifyRejectsWrongAudience(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	signed, err := km.IssueToken(Claims{}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	if _, err := km.ParseAndVerify(context.Background(), signed, "", "expected-audience"); err == nil {
+		t.Error("expected verification to fail when the token carries no matching audience")
+	}
+}
+
+func TestKeyManagerParseAndVerifyRejectsExpiredToken(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	signed, err := km.IssueToken(Claims{}, -time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	if _, err := km.ParseAndVerify(context.Background(), signed, "", ""); err == nil {
+		t.Error("expected verification to fail for an already-expired token")
+	}
+}
+
+func TestKeyManagerRotateRetiresOldKeyAfterOverlap(t *testing.T) {
+	km, err := NewKeyManager(0)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	signed, err := km.IssueToken(Claims{}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	if err := km.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	// With a zero overlap, the key that signed the token above is dropped
+	// the moment a newer key is promoted.
+	if _, err := km.ParseAndVerify(context.Background(), signed, "", ""); err == nil {
+		t.Error("expected a token signed by a retired, out-of-overlap key to fail verification")
+	}
+}
+
+func TestKeyManagerRotateKeepsOldKeyWithinOverlap(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	signed, err := km.IssueToken(Claims{}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	if err := km.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	// A one-hour overlap means the key that signed the token above is still
+	// published and verifiable right after the rotation.
+	if _, err := km.ParseAndVerify(context.Background(), signed, "", ""); err != nil {
+		t.Errorf("expected a token signed just before rotation to still verify within the overlap window: %v", err)
+	}
+}
+
+func TestKeyManagerParseAndVerifyRejectsMalformedToken(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	if _, err := km.ParseAndVerify(context.Background(), "not.a.token", "", ""); err == nil {
+		t.Error("expected a malformed token to fail parsing")
+	}
+}
+
+func TestKeyManagerServeJWKSPublishesActiveKey(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	km.ServeJWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("failed to decode jwks response: %v", err)
+	}
+	if len(set.Keys) != 1 {
+		t.Fatalf("expected 1 published key, got %d", len(set.Keys))
+	}
+	if set.Keys[0].KeyID != km.active().kid {
+		t.Errorf("expected the published key id to match the active key, got %q", set.Keys[0].KeyID)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testMiddlewareHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := FromContext(r.Context())
+		if !ok {
+			http.Error(w, "no claims in context", http.StatusInternalServerError)
+			return
+		}
+		if len(claims.Roles) > 0 {
+			w.Header().Set("X-Role", claims.Roles[0])
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestMiddlewareAcceptsBearerToken(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+	signed, err := km.IssueToken(Claims{Roles: []string{"admin"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	h := Middleware(km, "", "", "")(testMiddlewareHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Role"); got != "admin" {
+		t.Errorf("expected the handler to see the roles claim, got %q", got)
+	}
+}
+
+func TestMiddlewareFallsBackToCookie(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+	signed, err := km.IssueToken(Claims{}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	h := Middleware(km, "", "", "session")(testMiddlewareHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: signed})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareRejectsMissingToken(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	h := Middleware(km, "", "", "")(testMiddlewareHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a request with no token, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareRejectsInvalidToken(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+
+	h := Middleware(km, "", "", "")(testMiddlewareHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer not-a-valid-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an invalid token, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareRejectsWrongIssuer(t *testing.T) {
+	km, err := NewKeyManager(time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+	signed, err := km.IssueToken(Claims{}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken failed: %v", err)
+	}
+
+	h := Middleware(km, "https://expected-issuer.example.com", "", "")(testMiddlewareHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token with no matching issuer, got %d", w.Code)
+	}
+}
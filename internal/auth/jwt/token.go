@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/auth/jwt/token.go
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
+)
+
+// Claims is what IssueToken signs and ParseAndVerify returns. Roles is an
+// application-specific extra on top of the registered claims.
+type Claims struct {
+	josejwt.Claims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// IssueToken signs claims with the manager's active key, stamping exp from
+// ttl and a kid header so ParseAndVerify (or any external verifier using
+// ServeJWKS) can pick the right verification key.
+func (km *KeyManager) IssueToken(claims Claims, ttl time.Duration) (string, error) {
+	key := km.active()
+
+	now := time.Now()
+	claims.IssuedAt = josejwt.NewNumericDate(now)
+	claims.Expiry = josejwt.NewNumericDate(now.Add(ttl))
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: key.alg, Key: key.priv},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", key.kid),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to build signer: %w", err)
+	}
+
+	token, err := josejwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, nil
+}
+
+// ParseAndVerify verifies raw against the key named by its kid header (via
+// the manager's ring, including retired-but-still-in-overlap keys) and
+// checks exp/nbf/iss/aud.
+func (km *KeyManager) ParseAndVerify(ctx context.Context, raw string, issuer, audience string) (*Claims, error) {
+	token, err := josejwt.ParseSigned(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	if len(token.Headers) == 0 {
+		return nil, fmt.Errorf("token has no headers")
+	}
+
+	pub, _, ok := km.lookup(token.Headers[0].KeyID)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %q", token.Headers[0].KeyID)
+	}
+
+	var claims Claims
+	if err := token.Claims(pub, &claims); err != nil {
+		return nil, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	expected := josejwt.Expected{Time: time.Now()}
+	if issuer != "" {
+		expected.Issuer = issuer
+	}
+	if audience != "" {
+		expected.Audience = josejwt.Audience{audience}
+	}
+	if err := claims.Validate(expected); err != nil {
+		return nil, fmt.Errorf("claims validation failed: %w", err)
+	}
+
+	return &claims, nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
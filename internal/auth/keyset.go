@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/auth/keyset.go
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet selects the key used to sign new tokens and resolves the
+// verification key for a previously-issued token by its `kid` header. This
+// is what lets operators rotate signing keys without invalidating tokens
+// signed with a key that was current a moment ago.
+type KeySet interface {
+	// Current returns the kid and signer currently used for new tokens.
+	Current() (kid string, priv crypto.Signer, alg jwt.SigningMethod)
+	// Lookup resolves the public key and algorithm for kid.
+	Lookup(kid string) (pub crypto.PublicKey, alg jwt.SigningMethod, ok bool)
+}
+
+// hmacSecret adapts a shared HS256 secret so it can be stored and passed
+// around the same way as an asymmetric crypto.Signer. jwt/v5's HMAC signing
+// method works directly off the raw bytes rather than calling Sign, so the
+// Sign method here only exists to satisfy the crypto.Signer contract.
+type hmacSecret []byte
+
+func (s hmacSecret) Public() crypto.PublicKey { return []byte(s) }
+
+func (s hmacSecret) Sign(_ io.Reader, _ []byte, _ crypto.SignerOpts) ([]byte, error) {
+	return nil, fmt.Errorf("hmacSecret: not a real signer, use the raw secret with jwt.SigningMethodHS256")
+}
+
+// staticKeySet is a degenerate, single-key KeySet that wraps the legacy
+// shared HS256 secret, kept around so deployments that have not rotated to
+// asymmetric keys yet keep working unchanged.
+type staticKeySet struct {
+	kid    string
+	secret hmacSecret
+}
+
+func newStaticKeySet(secret []byte) *staticKeySet {
+	return &staticKeySet{kid: "static-hs256", secret: hmacSecret(secret)}
+}
+
+func (s *staticKeySet) Current() (string, crypto.Signer, jwt.SigningMethod) {
+	return s.kid, s.secret, jwt.SigningMethodHS256
+}
+
+func (s *staticKeySet) Lookup(kid string) (crypto.PublicKey, jwt.SigningMethod, bool) {
+	if kid != s.kid {
+		return nil, nil, false
+	}
+	return []byte(s.secret), jwt.SigningMethodHS256, true
+}
+
+// namedKey is one entry in a rotation set: a signer, the signing method it
+// was generated for, and the point at which it should stop being offered
+// for verification.
+type namedKey struct {
+	kid       string
+	priv      crypto.Signer
+	alg       jwt.SigningMethod
+	expiresAt time.Time
+}
+
+// FileKeySet is a KeySet backed by a directory of PEM-encoded private keys.
+// The most recently modified, non-expired key is used to sign new tokens;
+// every non-expired key in the directory remains available for Lookup so
+// tokens signed with an older key still verify. Call Watch to pick up keys
+// dropped into the directory after startup (e.g. during a rotation).
+type FileKeySet struct {
+	mu   sync.RWMutex
+	dir  string
+	keys map[string]*namedKey
+	// order holds kids from newest to oldest file mtime; keys[order[0]] is
+	// the current signing key.
+	order []string
+}
+
+// NewFileKeySet loads every `*.pem` file in dir and returns a KeySet backed
+// by them. The kid of each key is its filename without extension.
+func NewFileKeySet(dir string) (*FileKeySet, error) {
+	ks := &FileKeySet{dir: dir, keys: make(map[string]*namedKey)}
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Watch polls the key directory every interval and reloads it on change.
+// It returns a stop function that halts the watcher.
+func (ks *FileKeySet) Watch(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = ks.reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (ks *FileKeySet) reload() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	type loaded struct {
+		key   *namedKey
+		mtime time.Time
+	}
+	var loadedKeys []loaded
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat key file %s: %w", entry.Name(), err)
+		}
+
+		key, err := loadKeyFile(filepath.Join(ks.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to load key file %s: %w", entry.Name(), err)
+		}
+		key.kid = strings.TrimSuffix(entry.Name(), ".pem")
+		loadedKeys = append(loadedKeys, loaded{key: key, mtime: info.ModTime()})
+	}
+
+	if len(loadedKeys) == 0 {
+		return fmt.Errorf("no PEM keys found in %s", ks.dir)
+	}
+
+	sort.Slice(loadedKeys, func(i, j int) bool { return loadedKeys[i].mtime.After(loadedKeys[j].mtime) })
+
+	keys := make(map[string]*namedKey, len(loadedKeys))
+	order := make([]string, 0, len(loadedKeys))
+	for _, l := range loadedKeys {
+		keys[l.key.kid] = l.key
+		order = append(order, l.key.kid)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.order = order
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *FileKeySet) Current() (string, crypto.Signer, jwt.SigningMethod) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	for _, kid := range ks.order {
+		k := ks.keys[kid]
+		if k.expiresAt.IsZero() || k.expiresAt.After(now) {
+			return k.kid, k.priv, k.alg
+		}
+	}
+	// All keys expired: fall back to the newest one rather than signing
+	// with nothing.
+	k := ks.keys[ks.order[0]]
+	return k.kid, k.priv, k.alg
+}
+
+func (ks *FileKeySet) Lookup(kid string) (crypto.PublicKey, jwt.SigningMethod, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	k, ok := ks.keys[kid]
+	if !ok || (!k.expiresAt.IsZero() && k.expiresAt.Before(time.Now())) {
+		return nil, nil, false
+	}
+	return k.priv.Public(), k.alg, true
+}
+
+// publicKeys returns every non-expired key, used by the JWKS handler.
+func (ks *FileKeySet) publicKeys() []*namedKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	out := make([]*namedKey, 0, len(ks.order))
+	for _, kid := range ks.order {
+		k := ks.keys[kid]
+		if k.expiresAt.IsZero() || k.expiresAt.After(now) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func loadKeyFile(path string) (*namedKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	raw, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+
+	switch priv := raw.(type) {
+	case *rsa.PrivateKey:
+		return &namedKey{priv: priv, alg: jwt.SigningMethodRS256}, nil
+	case *ecdsa.PrivateKey:
+		return &namedKey{priv: priv, alg: ecdsaSigningMethod(priv)}, nil
+	case ed25519.PrivateKey:
+		return &namedKey{priv: priv, alg: jwt.SigningMethodEdDSA}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", raw)
+	}
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("PKCS8 key does not implement crypto.Signer")
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unrecognized private key encoding")
+}
+
+func ecdsaSigningMethod(priv *ecdsa.PrivateKey) jwt.SigningMethod {
+	switch priv.Curve.Params().BitSize {
+	case 384:
+		return jwt.SigningMethodES384
+	case 521:
+		return jwt.SigningMethodES512
+	default:
+		return jwt.SigningMethodES256
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKeyFile writes an ed25519 private key PEM file named kid+".pem" in
+// dir, backdating its mtime by age so FileKeySet's most-recently-modified
+// ordering can be exercised deterministically.
+func writeKeyFile(t *testing.T, dir, kid string, age time.Duration) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	path := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+}
+
+func TestFileKeySetSignsWithNewestKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyFile(t, dir, "old", time.Hour)
+	writeKeyFile(t, dir, "new", 0)
+
+	ks, err := NewFileKeySet(dir)
+	if err != nil {
+		t.Fatalf("NewFileKeySet failed: %v", err)
+	}
+
+	kid, _, alg := ks.Current()
+	if kid != "new" {
+		t.Errorf("expected the most recently modified key %q to be current, got %q", "new", kid)
+	}
+	if alg != jwt.SigningMethodEdDSA {
+		t.Errorf("expected EdDSA, got %v", alg)
+	}
+}
+
+func TestFileKeySetLookupVerifiesOlderKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyFile(t, dir, "old", time.Hour)
+	writeKeyFile(t, dir, "new", 0)
+
+	ks, err := NewFileKeySet(dir)
+	if err != nil {
+		t.Fatalf("NewFileKeySet failed: %v", err)
+	}
+
+	// A token signed with the older key must still verify, so rotating in a
+	// new signing key doesn't invalidate tokens already issued.
+	pub, alg, ok := ks.Lookup("old")
+	if !ok {
+		t.Fatal("expected Lookup to resolve the older, non-current key")
+	}
+	if alg != jwt.SigningMethodEdDSA {
+		t.Errorf("expected EdDSA, got %v", alg)
+	}
+	if _, ok := pub.(ed25519.PublicKey); !ok {
+		t.Errorf("expected an ed25519.PublicKey, got %T", pub)
+	}
+
+	if _, _, ok := ks.Lookup("unknown-kid"); ok {
+		t.Error("expected Lookup of an unknown kid to fail")
+	}
+}
+
+func TestFileKeySetReloadPicksUpRotatedKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyFile(t, dir, "v1", time.Hour)
+
+	ks, err := NewFileKeySet(dir)
+	if err != nil {
+		t.Fatalf("NewFileKeySet failed: %v", err)
+	}
+	if kid, _, _ := ks.Current(); kid != "v1" {
+		t.Fatalf("expected v1 to be current before rotation, got %q", kid)
+	}
+
+	// Simulate an operator dropping a freshly generated key into the
+	// directory: reload (what Watch does on its ticker) must pick it up as
+	// the new signing key while still leaving v1 verifiable.
+	writeKeyFile(t, dir, "v2", 0)
+	if err := ks.reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+
+	if kid, _, _ := ks.Current(); kid != "v2" {
+		t.Errorf("expected v2 to be current after rotation, got %q", kid)
+	}
+	if _, _, ok := ks.Lookup("v1"); !ok {
+		t.Error("expected the rotated-out key to remain resolvable for already-issued tokens")
+	}
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/auth/oidc.go
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// OIDCProviderConfig configures a single OpenID Connect relying party.
+// Issuer's `/.well-known/openid-configuration` is fetched once by
+// NewOIDCProvider per RFC 8414.
+type OIDCProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type oidcDiscoveryDoc struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDClaims is the set of ID token claims OIDCProvider.VerifyIDToken returns.
+type IDClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// OIDCProvider is a relying party for a single OIDC issuer: it performs
+// discovery once, keeps a cached JWKS that refreshes in the background
+// whenever a token names an unknown kid, and drives the authorization-code
+// + PKCE flow. Unlike connectors.Registry (which owns state issuance and
+// the local-user upsert), OIDCProvider is the lower-level primitive: the
+// caller manages the state/nonce/code_verifier cookies and what happens
+// after VerifyIDToken succeeds.
+type OIDCProvider struct {
+	cfg       OIDCProviderConfig
+	client    *http.Client
+	discovery oidcDiscoveryDoc
+
+	jwksMu   sync.RWMutex
+	jwks     jwk.Set
+	fetching atomic.Bool
+}
+
+// NewOIDCProvider performs discovery and an initial JWKS fetch, returning a
+// ready-to-use OIDCProvider.
+func NewOIDCProvider(ctx context.Context, cfg OIDCProviderConfig) (*OIDCProvider, error) {
+	p := &OIDCProvider{cfg: cfg, client: http.DefaultClient}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build discovery request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oidc discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&p.discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode oidc discovery document: %w", err)
+	}
+
+	if err := p.refreshJWKS(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch initial jwks: %w", err)
+	}
+	return p, nil
+}
+
+func (p *OIDCProvider) refreshJWKS(ctx context.Context) error {
+	set, err := jwk.Fetch(ctx, p.discovery.JWKSURI, jwk.WithHTTPClient(p.client))
+	if err != nil {
+		return err
+	}
+	p.jwksMu.Lock()
+	p.jwks = set
+	p.jwksMu.Unlock()
+	return nil
+}
+
+// refreshJWKSAsync kicks off a single background refresh, coalescing
+// concurrent kid-misses into one fetch rather than one per caller.
+func (p *OIDCProvider) refreshJWKSAsync() {
+	if !p.fetching.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.fetching.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = p.refreshJWKS(ctx)
+	}()
+}
+
+// GeneratePKCE returns a random code_verifier and its S256 code_challenge,
+// per RFC 7636.
+func GeneratePKCE() (verifier, challenge string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(raw)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	return verifier, challenge, nil
+}
+
+// AuthCodeURL builds the provider's authorization endpoint URL for state,
+// nonce, and a PKCE code_challenge produced by GeneratePKCE.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	return p.discovery.AuthorizationEndpoint + "?" + q.Encode()
+}
+
+// TokenSet is what Exchange returns: the provider's token response, with
+// IDToken still to be passed to VerifyIDToken.
+type TokenSet struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	ExpiresIn    time.Duration
+}
+
+// Exchange trades an authorization code and its PKCE code_verifier for a
+// TokenSet at the provider's token endpoint.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenSet, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokenResp.Error != "" {
+		return nil, fmt.Errorf("oidc token error: %s", tokenResp.Error)
+	}
+	if tokenResp.IDToken == "" {
+		return nil, fmt.Errorf("token response contained no id_token")
+	}
+
+	return &TokenSet{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		IDToken:      tokenResp.IDToken,
+		ExpiresIn:    time.Duration(tokenResp.ExpiresIn) * time.Second,
+	}, nil
+}
+
+// VerifyIDToken verifies raw's signature against the cached JWKS and checks
+// iss/aud/exp; it does not check nonce - the caller compares
+// IDClaims.Nonce against whatever it stored for this login attempt (e.g. a
+// cookie), since OIDCProvider itself is not request-scoped.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw string) (*IDClaims, error) {
+	var claims IDClaims
+	token, err := jwt.ParseWithClaims(raw, &claims, p.keyfunc(ctx),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("id token is not valid")
+	}
+	return &claims, nil
+}
+
+// keyfunc resolves a token's kid against the cached JWKS. A miss triggers a
+// single background refresh (coalesced across concurrent callers) and fails
+// the current verification - the next request, after the refresh lands,
+// succeeds. This is deliberately non-blocking: a flood of tokens signed
+// with a not-yet-cached kid must not serialize behind one HTTP fetch.
+func (p *OIDCProvider) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		p.jwksMu.RLock()
+		set := p.jwks
+		p.jwksMu.RUnlock()
+
+		if set != nil {
+			if key, ok := set.LookupKeyID(kid); ok {
+				var rawKey interface{}
+				if err := key.Raw(&rawKey); err != nil {
+					return nil, fmt.Errorf("failed to materialize jwks key: %w", err)
+				}
+				return rawKey, nil
+			}
+		}
+
+		p.refreshJWKSAsync()
+		return nil, fmt.Errorf("unknown kid: %q (jwks refresh in progress)", kid)
+	}
+}
@@ -18,10 +18,14 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"golang.org/x/crypto/argon2"
@@ -29,19 +33,68 @@ import (
 
 const (
 	// Argon2 parameters
-	saltLength   = 32
-	keyLength    = 32
-	argon2Time   = 3
-	memory       = 64 * 1024
-	threads      = 4
+	saltLength = 32
+	keyLength  = 32
+	argon2Time = 3
+	memory     = 64 * 1024
+	threads    = 4
 )
 
+// hashParams holds the Argon2id cost parameters encoded alongside a hash, so
+// VerifyPassword can recompute with whatever parameters a given hash was
+// actually produced with instead of always assuming the current policy.
+type hashParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+}
+
+func currentParams() hashParams {
+	return hashParams{time: argon2Time, memory: memory, threads: threads, keyLen: keyLength}
+}
+
+// weakerThan reports whether p falls short of want on any dimension, meaning
+// a hash encoded with p should be upgraded to want on next successful login.
+func (p hashParams) weakerThan(want hashParams) bool {
+	return p.time < want.time || p.memory < want.memory || p.threads < want.threads || p.keyLen < want.keyLen
+}
+
 // PasswordHasher handles password hashing and verification
-type PasswordHasher struct{}
+type PasswordHasher struct {
+	params hashParams
+
+	// pepper, if set, is HMAC-SHA256'd with the password before it reaches
+	// Argon2. pepperID is embedded in the encoded hash (as a $k=<id> field)
+	// so a future pepper rotation can tell old hashes apart from new ones.
+	pepper   []byte
+	pepperID string
+}
 
 // NewPasswordHasher creates a new password hasher
 func NewPasswordHasher() *PasswordHasher {
-	return &PasswordHasher{}
+	return &PasswordHasher{params: currentParams()}
+}
+
+// NewPasswordHasherWithPepper creates a password hasher that additionally
+// HMAC-SHA256s every password with secret before hashing it with Argon2id.
+// secret is identified in the encoded hash by a pepperID derived from it, so
+// a later rotation to a different secret gets a different id automatically
+// — callers needing to verify hashes produced under an older pepper just
+// keep a PasswordHasher per known secret around, keyed by that id.
+func NewPasswordHasherWithPepper(secret []byte) *PasswordHasher {
+	id := sha256.Sum256(secret)
+	return &PasswordHasher{params: currentParams(), pepper: secret, pepperID: hex.EncodeToString(id[:4])}
+}
+
+// pepperedPassword applies the HMAC-SHA256 pepper to password, if configured.
+func (ph *PasswordHasher) pepperedPassword(password string) []byte {
+	if ph.pepper == nil {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, ph.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
 }
 
 // HashPassword hashes a password using Argon2id
@@ -51,57 +104,117 @@ func (ph *PasswordHasher) HashPassword(password string) (string, error) {
 		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, argon2Time, memory, threads, keyLength)
+	p := ph.params
+	hash := argon2.IDKey(ph.pepperedPassword(password), salt, p.time, p.memory, p.threads, p.keyLen)
 
-	// Encode salt and hash to base64
 	saltBase64 := base64.RawStdEncoding.EncodeToString(salt)
 	hashBase64 := base64.RawStdEncoding.EncodeToString(hash)
 
-	// Format: $argon2id$v=19$m=65536,t=3,p=4$salt$hash
-	encodedHash := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s",
-		memory, argon2Time, threads, saltBase64, hashBase64)
-
-	return encodedHash, nil
+	// Format: $argon2id$v=19$m=65536,t=3,p=4,l=32[$k=<pepper_id>]$salt$hash
+	params := fmt.Sprintf("m=%d,t=%d,p=%d,l=%d", p.memory, p.time, p.threads, p.keyLen)
+	if ph.pepper != nil {
+		return fmt.Sprintf("$argon2id$v=19$%s$k=%s$%s$%s", params, ph.pepperID, saltBase64, hashBase64), nil
+	}
+	return fmt.Sprintf("$argon2id$v=19$%s$%s$%s", params, saltBase64, hashBase64), nil
 }
 
 // VerifyPassword verifies a password against its hash
 func (ph *PasswordHasher) VerifyPassword(password, hashedPassword string) bool {
-	salt, hash, err := ph.decodeHash(hashedPassword)
+	p, _, salt, hash, err := ph.decodeHash(hashedPassword)
 	if err != nil {
 		return false
 	}
 
-	otherHash := argon2.IDKey([]byte(password), salt, argon2Time, memory, threads, keyLength)
+	otherHash := argon2.IDKey(ph.pepperedPassword(password), salt, p.time, p.memory, p.threads, p.keyLen)
 
 	return subtle.ConstantTimeCompare(hash, otherHash) == 1
 }
 
-// decodeHash decodes the hash string and extracts salt and hash
-func (ph *PasswordHasher) decodeHash(encodedHash string) (salt, hash []byte, err error) {
+// NeedsRehash reports whether hashedPassword was produced with weaker
+// parameters than the hasher's current policy, so a caller can re-hash the
+// password (with HashPassword) right after a successful VerifyPassword.
+func (ph *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	p, _, _, _, err := ph.decodeHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return p.weakerThan(ph.params)
+}
+
+// decodeHash decodes the hash string, returning its Argon2 parameters, the
+// pepper id it was encoded with (empty if none), and the raw salt and hash
+// bytes.
+func (ph *PasswordHasher) decodeHash(encodedHash string) (p hashParams, pepperID string, salt, hash []byte, err error) {
 	parts := strings.Split(encodedHash, "$")
-	if len(parts) != 6 {
-		return nil, nil, fmt.Errorf("invalid hash format")
+	// Without a pepper field: ["", "argon2id", "v=19", params, salt, hash]
+	// With a pepper field:    ["", "argon2id", "v=19", params, "k=<id>", salt, hash]
+	if len(parts) != 6 && len(parts) != 7 {
+		return hashParams{}, "", nil, nil, fmt.Errorf("invalid hash format")
 	}
 
 	if parts[1] != "argon2id" {
-		return nil, nil, fmt.Errorf("incompatible hash algorithm")
+		return hashParams{}, "", nil, nil, fmt.Errorf("incompatible hash algorithm")
 	}
 
 	if parts[2] != "v=19" {
-		return nil, nil, fmt.Errorf("incompatible argon2 version")
+		return hashParams{}, "", nil, nil, fmt.Errorf("incompatible argon2 version")
 	}
 
-	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	p, err = parseHashParams(parts[3])
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to decode salt: %w", err)
+		return hashParams{}, "", nil, nil, err
 	}
 
-	hash, err = base64.RawStdEncoding.DecodeString(parts[5])
+	saltField, hashField := parts[4], parts[5]
+	if len(parts) == 7 {
+		if !strings.HasPrefix(parts[4], "k=") {
+			return hashParams{}, "", nil, nil, fmt.Errorf("invalid pepper field")
+		}
+		pepperID = strings.TrimPrefix(parts[4], "k=")
+		saltField, hashField = parts[5], parts[6]
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(saltField)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to decode hash: %w", err)
+		return hashParams{}, "", nil, nil, fmt.Errorf("failed to decode salt: %w", err)
 	}
 
-	return salt, hash, nil
+	hash, err = base64.RawStdEncoding.DecodeString(hashField)
+	if err != nil {
+		return hashParams{}, "", nil, nil, fmt.Errorf("failed to decode hash: %w", err)
+	}
+
+	return p, pepperID, salt, hash, nil
+}
+
+// parseHashParams parses the "m=...,t=...,p=...[,l=...]" field. l (key
+// length) is optional so hashes encoded before it was added still decode,
+// defaulting to the package's current keyLength.
+func parseHashParams(field string) (hashParams, error) {
+	p := hashParams{keyLen: keyLength}
+	for _, kv := range strings.Split(field, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return hashParams{}, fmt.Errorf("invalid hash parameter %q", kv)
+		}
+		value, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return hashParams{}, fmt.Errorf("invalid hash parameter %q: %w", kv, err)
+		}
+		switch parts[0] {
+		case "m":
+			p.memory = uint32(value)
+		case "t":
+			p.time = uint32(value)
+		case "p":
+			p.threads = uint8(value)
+		case "l":
+			p.keyLen = uint32(value)
+		default:
+			return hashParams{}, fmt.Errorf("unknown hash parameter %q", kv)
+		}
+	}
+	return p, nil
 }
 
 // generateRandomSalt generates a random salt of the specified length
@@ -120,4 +233,4 @@ func GenerateSecureToken(length int) (string, error) {
 		return "", err
 	}
 	return base64.URLEncoding.EncodeToString(bytes), nil
-}
\ No newline at end of file
+}
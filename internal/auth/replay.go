@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayStore records which token ids (`jti`) have already been presented,
+// so a refresh token (or any token issued with RequireJTI) can only be used
+// once even if it is stolen before it expires.
+type ReplayStore interface {
+	// SeenBefore atomically checks whether jti was already recorded and, if
+	// not, records it with a TTL derived from exp. It returns true if jti
+	// had already been seen, i.e. the caller is looking at a replay.
+	SeenBefore(jti string, exp time.Time) bool
+}
+
+// InMemoryReplayStore is a process-local ReplayStore backed by a TTL map.
+// It is the default for single-instance deployments; multi-instance
+// deployments should use RedisReplayStore instead so replay protection is
+// shared across processes.
+type InMemoryReplayStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewInMemoryReplayStore creates an empty in-memory replay store.
+func NewInMemoryReplayStore() *InMemoryReplayStore {
+	return &InMemoryReplayStore{seen: make(map[string]time.Time)}
+}
+
+func (s *InMemoryReplayStore) SeenBefore(jti string, exp time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gcLocked()
+
+	if _, ok := s.seen[jti]; ok {
+		return true
+	}
+	s.seen[jti] = exp
+	return false
+}
+
+// gcLocked drops entries past their exp. Callers must hold s.mu.
+func (s *InMemoryReplayStore) gcLocked() {
+	now := time.Now()
+	for jti, exp := range s.seen {
+		if now.After(exp) {
+			delete(s.seen, jti)
+		}
+	}
+}
+
+// RedisReplayStore is a ReplayStore backed by Redis, for deployments that
+// run more than one API instance behind a load balancer and need replay
+// protection to be shared across them.
+type RedisReplayStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisReplayStore creates a RedisReplayStore using client, namespacing
+// keys under "jti:" so they don't collide with other uses of the same
+// Redis database.
+func NewRedisReplayStore(client *redis.Client) *RedisReplayStore {
+	return &RedisReplayStore{client: client, prefix: "jti:"}
+}
+
+func (s *RedisReplayStore) SeenBefore(jti string, exp time.Time) bool {
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		// Already expired: nothing to protect, but don't claim it's a
+		// replay either.
+		return false
+	}
+
+	ok, err := s.client.SetNX(context.Background(), s.prefix+jti, true, ttl).Result()
+	if err != nil {
+		// Fail closed: if we can't talk to Redis we can't guarantee
+		// one-shot use, so treat the token as already seen rather than
+		// risk a silent replay.
+		return true
+	}
+	// SetNX returns true if the key was newly set, i.e. this is the first
+	// time we've seen jti.
+	return !ok
+}
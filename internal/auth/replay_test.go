@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryReplayStoreFirstUseIsNotAReplay(t *testing.T) {
+	s := NewInMemoryReplayStore()
+
+	if s.SeenBefore("jti-1", time.Now().Add(time.Minute)) {
+		t.Error("expected the first use of a jti not to be flagged as a replay")
+	}
+}
+
+func TestInMemoryReplayStoreSecondUseIsAReplay(t *testing.T) {
+	s := NewInMemoryReplayStore()
+	exp := time.Now().Add(time.Minute)
+
+	s.SeenBefore("jti-1", exp)
+
+	if !s.SeenBefore("jti-1", exp) {
+		t.Error("expected a repeated jti to be flagged as a replay")
+	}
+}
+
+func TestInMemoryReplayStoreDistinctJTIsDontCollide(t *testing.T) {
+	s := NewInMemoryReplayStore()
+	exp := time.Now().Add(time.Minute)
+
+	s.SeenBefore("jti-1", exp)
+
+	if s.SeenBefore("jti-2", exp) {
+		t.Error("expected a different jti not to be flagged as a replay")
+	}
+}
+
+func TestInMemoryReplayStoreGCDropsExpiredEntries(t *testing.T) {
+	s := NewInMemoryReplayStore()
+
+	// Already expired by the time it's recorded.
+	s.SeenBefore("jti-old", time.Now().Add(-time.Minute))
+
+	// Recording a second jti triggers gcLocked, which should have dropped
+	// jti-old - so it's usable again as if it had never been seen.
+	s.SeenBefore("jti-new", time.Now().Add(time.Minute))
+
+	if s.SeenBefore("jti-old", time.Now().Add(time.Minute)) {
+		t.Error("expected an expired jti to have been garbage collected, not flagged as a replay")
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/config/loader.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// configFilePath resolves the optional config file, preferring a --config
+// (or -config) command-line flag over the CONFIG_FILE environment variable.
+// It's scanned by hand instead of through the flag package so Load doesn't
+// have to know about every other flag a binary built on this package might
+// define.
+func configFilePath() string {
+	args := os.Args[1:]
+	for i, a := range args {
+		switch {
+		case a == "--config" || a == "-config":
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		case strings.HasPrefix(a, "--config="):
+			return strings.TrimPrefix(a, "--config=")
+		case strings.HasPrefix(a, "-config="):
+			return strings.TrimPrefix(a, "-config=")
+		}
+	}
+	return os.Getenv("CONFIG_FILE")
+}
+
+// decodeConfigFile reads path and decodes it into a generic map, choosing
+// YAML/TOML/JSON by file extension.
+func decodeConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing yaml: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing toml: %w", err)
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing json: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized config file extension %q (want .yaml, .toml, or .json)", filepath.Ext(path))
+	}
+	return m, nil
+}
+
+// applyDefaults walks v's struct fields, setting any field that is still its
+// zero value to its `default:` tag, recursing into nested structs (but not
+// into time.Duration, which is a defined int64 kind, not a struct).
+func applyDefaults(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			applyDefaults(field)
+			continue
+		}
+
+		def, ok := sf.Tag.Lookup("default")
+		if !ok || !field.IsZero() {
+			continue
+		}
+		if err := setFieldFromString(field, def); err != nil {
+			// A bad `default:` tag is a programmer error in this package,
+			// not a runtime/ops problem, so surface it loudly.
+			panic(fmt.Sprintf("config: invalid default %q for %s: %v", def, sf.Name, err))
+		}
+	}
+}
+
+// applyEnv overlays environment variables named by each field's
+// `envconfig:` tag, recursing into nested structs.
+func applyEnv(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			applyEnv(field)
+			continue
+		}
+
+		key, ok := sf.Tag.Lookup("envconfig")
+		if !ok {
+			continue
+		}
+		value, present := os.LookupEnv(key)
+		if !present {
+			continue
+		}
+		if err := setFieldFromString(field, value); err != nil {
+			panic(fmt.Sprintf("config: invalid value %q for env %s: %v", value, key, err))
+		}
+	}
+}
+
+// applyMap overlays m onto v's struct fields, matching each field by its
+// `json:` tag (falling back to the lowercased Go field name), recursing
+// into nested structs. Lookups are case-insensitive since YAML/TOML authors
+// shouldn't have to match the Go tag's exact casing.
+func applyMap(v reflect.Value, m map[string]any) {
+	if m == nil {
+		return
+	}
+	lower := make(map[string]any, len(m))
+	for k, val := range m {
+		lower[strings.ToLower(k)] = val
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+		key := jsonFieldName(sf)
+
+		raw, ok := lower[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			nested, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			applyMap(field, nested)
+			continue
+		}
+
+		if err := setFieldFromString(field, fmt.Sprint(raw)); err != nil {
+			panic(fmt.Sprintf("config: invalid value %v for %s in config file: %v", raw, sf.Name, err))
+		}
+	}
+}
+
+// walkStringFields recurses through v's struct fields (like applyDefaults
+// and applyEnv), calling fn on every string-kinded field so a caller can
+// inspect or rewrite it in place.
+func walkStringFields(v reflect.Value, fn func(reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Struct && field.Type() != durationType:
+			if err := walkStringFields(field, fn); err != nil {
+				return err
+			}
+		case field.Kind() == reflect.String:
+			if err := fn(field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFieldName returns the `json:` tag name for sf (ignoring options like
+// ",omitempty"), or its lowercased Go field name if untagged.
+func jsonFieldName(sf reflect.StructField) string {
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok || tag == "" {
+		return strings.ToLower(sf.Name)
+	}
+	if comma := strings.IndexByte(tag, ','); comma >= 0 {
+		tag = tag[:comma]
+	}
+	if tag == "" {
+		return strings.ToLower(sf.Name)
+	}
+	return tag
+}
+
+// setFieldFromString parses s into field according to field's kind. time.
+// Duration is special-cased (time.ParseDuration) since it's otherwise
+// indistinguishable from a plain int64.
+func setFieldFromString(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// withArgs temporarily replaces os.Args for the duration of the test.
+func withArgs(t *testing.T, args ...string) {
+	t.Helper()
+	orig := os.Args
+	os.Args = append([]string{orig[0]}, args...)
+	t.Cleanup(func() { os.Args = orig })
+}
+
+func TestConfigFilePathFromFlag(t *testing.T) {
+	withArgs(t, "--config", "/etc/api/config.yaml")
+	if got := configFilePath(); got != "/etc/api/config.yaml" {
+		t.Errorf("expected /etc/api/config.yaml, got %q", got)
+	}
+}
+
+func TestConfigFilePathFromFlagEquals(t *testing.T) {
+	withArgs(t, "-config=/etc/api/config.toml")
+	if got := configFilePath(); got != "/etc/api/config.toml" {
+		t.Errorf("expected /etc/api/config.toml, got %q", got)
+	}
+}
+
+func TestConfigFilePathFromEnv(t *testing.T) {
+	withArgs(t)
+	t.Setenv("CONFIG_FILE", "/etc/api/config.json")
+	if got := configFilePath(); got != "/etc/api/config.json" {
+		t.Errorf("expected /etc/api/config.json, got %q", got)
+	}
+}
+
+func TestConfigFilePathFlagTakesPriorityOverEnv(t *testing.T) {
+	withArgs(t, "--config", "/from/flag.yaml")
+	t.Setenv("CONFIG_FILE", "/from/env.yaml")
+	if got := configFilePath(); got != "/from/flag.yaml" {
+		t.Errorf("expected the flag to win over CONFIG_FILE, got %q", got)
+	}
+}
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestDecodeConfigFileYAML(t *testing.T) {
+	path := writeTempFile(t, "cfg.yaml", "server:\n  port: 9000\n")
+	m, err := decodeConfigFile(path)
+	if err != nil {
+		t.Fatalf("decodeConfigFile failed: %v", err)
+	}
+	server, ok := m["server"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a server map, got %#v", m["server"])
+	}
+	if server["port"] != 9000 {
+		t.Errorf("expected port 9000, got %v", server["port"])
+	}
+}
+
+func TestDecodeConfigFileTOML(t *testing.T) {
+	path := writeTempFile(t, "cfg.toml", "[server]\nport = 9000\n")
+	m, err := decodeConfigFile(path)
+	if err != nil {
+		t.Fatalf("decodeConfigFile failed: %v", err)
+	}
+	server, ok := m["server"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a server map, got %#v", m["server"])
+	}
+	if server["port"] != int64(9000) {
+		t.Errorf("expected port 9000, got %v", server["port"])
+	}
+}
+
+func TestDecodeConfigFileJSON(t *testing.T) {
+	path := writeTempFile(t, "cfg.json", `{"server": {"port": 9000}}`)
+	m, err := decodeConfigFile(path)
+	if err != nil {
+		t.Fatalf("decodeConfigFile failed: %v", err)
+	}
+	server, ok := m["server"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a server map, got %#v", m["server"])
+	}
+	if server["port"] != float64(9000) {
+		t.Errorf("expected port 9000, got %v", server["port"])
+	}
+}
+
+func TestDecodeConfigFileUnrecognizedExtension(t *testing.T) {
+	path := writeTempFile(t, "cfg.ini", "port = 9000\n")
+	if _, err := decodeConfigFile(path); err == nil {
+		t.Error("expected an unrecognized extension to be rejected")
+	}
+}
+
+func TestDecodeConfigFileMissing(t *testing.T) {
+	if _, err := decodeConfigFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected reading a missing file to fail")
+	}
+}
+
+// testLayerConfig is a small, self-contained struct exercising the same tag
+// conventions as Config, without coupling these tests to its actual fields.
+type testLayerConfig struct {
+	Host    string        `json:"host" envconfig:"TESTCFG_HOST" default:"0.0.0.0"`
+	Port    int           `json:"port" envconfig:"TESTCFG_PORT" default:"8080"`
+	Timeout time.Duration `json:"timeout" envconfig:"TESTCFG_TIMEOUT" default:"5s"`
+	Nested  struct {
+		Name string `json:"name" envconfig:"TESTCFG_NESTED_NAME" default:"nested-default"`
+	} `json:"nested"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var cfg testLayerConfig
+	applyDefaults(reflect.ValueOf(&cfg).Elem())
+
+	if cfg.Host != "0.0.0.0" {
+		t.Errorf("expected default host, got %q", cfg.Host)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected default port, got %d", cfg.Port)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("expected default timeout, got %v", cfg.Timeout)
+	}
+	if cfg.Nested.Name != "nested-default" {
+		t.Errorf("expected a nested struct's default to be applied, got %q", cfg.Nested.Name)
+	}
+}
+
+func TestApplyDefaultsDoesNotOverrideNonZeroField(t *testing.T) {
+	cfg := testLayerConfig{Port: 1234}
+	applyDefaults(reflect.ValueOf(&cfg).Elem())
+
+	if cfg.Port != 1234 {
+		t.Errorf("expected the already-set port to survive, got %d", cfg.Port)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("TESTCFG_HOST", "10.0.0.5")
+	t.Setenv("TESTCFG_PORT", "9999")
+	t.Setenv("TESTCFG_NESTED_NAME", "from-env")
+
+	var cfg testLayerConfig
+	applyEnv(reflect.ValueOf(&cfg).Elem())
+
+	if cfg.Host != "10.0.0.5" {
+		t.Errorf("expected env host, got %q", cfg.Host)
+	}
+	if cfg.Port != 9999 {
+		t.Errorf("expected env port, got %d", cfg.Port)
+	}
+	if cfg.Nested.Name != "from-env" {
+		t.Errorf("expected a nested struct's env var to be applied, got %q", cfg.Nested.Name)
+	}
+}
+
+func TestApplyMapCaseInsensitiveAndNested(t *testing.T) {
+	var cfg testLayerConfig
+	applyMap(reflect.ValueOf(&cfg).Elem(), map[string]any{
+		"HOST": "192.168.1.1",
+		"nested": map[string]any{
+			"NAME": "from-file",
+		},
+	})
+
+	if cfg.Host != "192.168.1.1" {
+		t.Errorf("expected a case-insensitive match on HOST, got %q", cfg.Host)
+	}
+	if cfg.Nested.Name != "from-file" {
+		t.Errorf("expected the nested map to be applied, got %q", cfg.Nested.Name)
+	}
+}
+
+func TestLayeringOrderDefaultsThenFileThenEnv(t *testing.T) {
+	var cfg testLayerConfig
+	applyDefaults(reflect.ValueOf(&cfg).Elem())
+	applyMap(reflect.ValueOf(&cfg).Elem(), map[string]any{"port": 9000})
+
+	t.Setenv("TESTCFG_PORT", "7000")
+	applyEnv(reflect.ValueOf(&cfg).Elem())
+
+	if cfg.Port != 7000 {
+		t.Errorf("expected env to override the config file value, got %d", cfg.Port)
+	}
+	if cfg.Host != "0.0.0.0" {
+		t.Errorf("expected the untouched field to keep its default, got %q", cfg.Host)
+	}
+}
+
+func TestSetFieldFromStringDuration(t *testing.T) {
+	var cfg testLayerConfig
+	field := reflect.ValueOf(&cfg).Elem().FieldByName("Timeout")
+	if err := setFieldFromString(field, "30s"); err != nil {
+		t.Fatalf("setFieldFromString failed: %v", err)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("expected 30s, got %v", cfg.Timeout)
+	}
+}
+
+func TestSetFieldFromStringRejectsInvalidInt(t *testing.T) {
+	var cfg testLayerConfig
+	field := reflect.ValueOf(&cfg).Elem().FieldByName("Port")
+	if err := setFieldFromString(field, "not-a-number"); err == nil {
+		t.Error("expected an invalid int to be rejected")
+	}
+}
+
+func TestJSONFieldName(t *testing.T) {
+	sf, ok := reflect.TypeOf(testLayerConfig{}).FieldByName("Timeout")
+	if !ok {
+		t.Fatal("expected to find the Timeout field")
+	}
+	if got := jsonFieldName(sf); got != "timeout" {
+		t.Errorf("expected %q, got %q", "timeout", got)
+	}
+}
+
+func TestJSONFieldNameFallsBackToLoweredGoName(t *testing.T) {
+	type untagged struct {
+		Foo string
+	}
+	sf, ok := reflect.TypeOf(untagged{}).FieldByName("Foo")
+	if !ok {
+		t.Fatal("expected to find the Foo field")
+	}
+	if got := jsonFieldName(sf); got != "foo" {
+		t.Errorf("expected %q, got %q", "foo", got)
+	}
+}
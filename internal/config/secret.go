@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/config/secret.go
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// SecretResolver resolves the ref half of a "${scheme:ref}" config value
+// (everything after the first colon) to its actual secret value. Plain
+// strings that don't match that syntax are left untouched, so
+// "env-literal" values (the vast majority of config) never touch a
+// resolver at all.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// secretRefPattern matches a whole field value of the form
+// "${scheme:ref}", e.g. "${file:/run/secrets/jwt}" or
+// "${vault:secret/data/api#jwt}". Partial/embedded references aren't
+// supported; a field is either a literal or a single reference.
+var secretRefPattern = regexp.MustCompile(`^\$\{([a-zA-Z0-9]+):(.+)\}$`)
+
+// secretResolvers holds the resolvers Load wires up, plus whatever cleanup
+// they need (e.g. the Vault resolver's lease-renewal goroutine). It's
+// attached to Config unexported so Config.Close can stop them.
+type secretResolvers struct {
+	byScheme map[string]SecretResolver
+	closers  []func()
+}
+
+func (r *secretResolvers) Close() {
+	for _, stop := range r.closers {
+		stop()
+	}
+}
+
+// newSecretResolvers builds the default scheme registry. The Vault
+// resolver is only wired up (and its renewal goroutine only started) once
+// a config value actually references it, so a deployment that doesn't use
+// Vault never dials out or spawns the background goroutine.
+func newSecretResolvers() *secretResolvers {
+	r := &secretResolvers{byScheme: map[string]SecretResolver{
+		"file": fileSecretResolver{},
+	}}
+	return r
+}
+
+// resolverFor lazily constructs and caches the resolver for scheme,
+// deferring any work (env lookups, auth) until the scheme is first used.
+func (r *secretResolvers) resolverFor(scheme string) (SecretResolver, error) {
+	if res, ok := r.byScheme[scheme]; ok {
+		return res, nil
+	}
+
+	switch scheme {
+	case "vault":
+		res, stop, err := newVaultSecretResolver()
+		if err != nil {
+			return nil, err
+		}
+		r.byScheme[scheme] = res
+		r.closers = append(r.closers, stop)
+		return res, nil
+	case "awssm":
+		res, err := newAWSSMSecretResolver()
+		if err != nil {
+			return nil, err
+		}
+		r.byScheme[scheme] = res
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unknown secret scheme %q", scheme)
+	}
+}
+
+// resolveSecretRefs walks v's string fields (recursing into nested
+// structs, time.Duration excluded since it can never match), replacing
+// any value shaped like "${scheme:ref}" with what the matching
+// SecretResolver returns.
+func resolveSecretRefs(ctx context.Context, v reflect.Value, r *secretResolvers) error {
+	return walkStringFields(v, func(field reflect.Value) error {
+		s := field.String()
+		m := secretRefPattern.FindStringSubmatch(s)
+		if m == nil {
+			return nil
+		}
+		scheme, ref := m[1], m[2]
+
+		resolver, err := r.resolverFor(scheme)
+		if err != nil {
+			return fmt.Errorf("resolving %q: %w", s, err)
+		}
+		resolved, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("resolving %q: %w", s, err)
+		}
+		field.SetString(resolved)
+		return nil
+	})
+}
+
+// fileSecretResolver reads the secret from a file on disk, e.g. a
+// Kubernetes/Docker secret mounted at /run/secrets/jwt. Trailing newlines
+// (the common case for files written by `echo` or a secrets CSI driver)
+// are trimmed.
+type fileSecretResolver struct{}
+
+func (fileSecretResolver) Resolve(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
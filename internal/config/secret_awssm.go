@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/config/secret_awssm.go
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const awsSecretsManagerService = "secretsmanager"
+
+// awssmSecretResolver resolves "${awssm:<secret-id>}" references against
+// AWS Secrets Manager's GetSecretValue API. There's no official Go SDK
+// dependency here (internal/logger/otlp_proto.go makes the same choice for
+// OTLP) — the
+// GetSecretValue request is a single signed HTTPS POST, so a hand-rolled
+// SigV4 signer is a much smaller footprint than pulling in aws-sdk-go-v2.
+type awssmSecretResolver struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+}
+
+func newAWSSMSecretResolver() (SecretResolver, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("config: awssm secret referenced but AWS_REGION is not set")
+	}
+	accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("config: awssm secret referenced but AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY are not set")
+	}
+
+	return &awssmSecretResolver{
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Resolve calls GetSecretValue for ref (the secret's name or ARN) and
+// returns its string value, preferring SecretString (the common case) over
+// the base64 SecretBinary form.
+func (a *awssmSecretResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	host := fmt.Sprintf("%s.%s.amazonaws.com", awsSecretsManagerService, a.region)
+	body, err := json.Marshal(map[string]string{"SecretId": ref})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	req.Host = host
+
+	a.signSigV4(req, host, body, time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("secretsmanager GetSecretValue %q: %s: %s", ref, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return "", err
+	}
+	if out.SecretString != "" {
+		return out.SecretString, nil
+	}
+	return out.SecretBinary, nil
+}
+
+// signSigV4 signs req per AWS Signature Version 4
+// (docs.aws.amazon.com/general/latest/gr/sigv4-signing-process), adding
+// the X-Amz-Date and Authorization headers in place.
+func (a *awssmSecretResolver) signSigV4(req *http.Request, host string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	headerValues := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	signedHeaders := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if a.sessionToken != "" {
+		headerValues["x-amz-security-token"] = a.sessionToken
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range signedHeaders {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", h, strings.TrimSpace(headerValues[h]))
+	}
+	signedHeadersStr := strings.Join(signedHeaders, ";")
+
+	payloadHash := sha256Hex(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"", // no query string
+		canonicalHeaders.String(),
+		signedHeadersStr,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", dateStamp, a.region, awsSecretsManagerService)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := sigV4Key(a.secretAccessKey, dateStamp, a.region, awsSecretsManagerService)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKeyID, credentialScope, signedHeadersStr, signature)
+	req.Header.Set("Authorization", authHeader)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sigV4Key derives the request-scoped signing key through AWS's
+// date -> region -> service -> aws4_request HMAC chain.
+func sigV4Key(secretAccessKey, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secretAccessKey), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSecretRefPatternMatchesSchemeAndRef(t *testing.T) {
+	m := secretRefPattern.FindStringSubmatch("${file:/run/secrets/jwt}")
+	if m == nil {
+		t.Fatal("expected the pattern to match")
+	}
+	if m[1] != "file" || m[2] != "/run/secrets/jwt" {
+		t.Errorf("expected scheme %q ref %q, got scheme %q ref %q", "file", "/run/secrets/jwt", m[1], m[2])
+	}
+}
+
+func TestSecretRefPatternMatchesRefContainingHash(t *testing.T) {
+	m := secretRefPattern.FindStringSubmatch("${vault:secret/data/api#jwt}")
+	if m == nil {
+		t.Fatal("expected the pattern to match a vault ref containing '#'")
+	}
+	if m[1] != "vault" || m[2] != "secret/data/api#jwt" {
+		t.Errorf("expected scheme %q ref %q, got scheme %q ref %q", "vault", "secret/data/api#jwt", m[1], m[2])
+	}
+}
+
+func TestSecretRefPatternLeavesPlainStringsUnmatched(t *testing.T) {
+	if secretRefPattern.FindStringSubmatch("a plain config value") != nil {
+		t.Error("expected a plain string not to match")
+	}
+	if secretRefPattern.FindStringSubmatch("${file:/path} trailing") != nil {
+		t.Error("expected a reference with trailing text not to match (whole-value only)")
+	}
+}
+
+func TestFileSecretResolverResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	got, err := (fileSecretResolver{}).Resolve(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Errorf("expected the trailing newline to be trimmed, got %q", got)
+	}
+}
+
+func TestFileSecretResolverResolveMissingFile(t *testing.T) {
+	if _, err := (fileSecretResolver{}).Resolve(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected resolving a missing file to fail")
+	}
+}
+
+func TestResolverForUnknownScheme(t *testing.T) {
+	r := newSecretResolvers()
+	if _, err := r.resolverFor("carrier-pigeon"); err == nil {
+		t.Error("expected an unknown scheme to be rejected")
+	}
+}
+
+func TestResolverForCachesFileResolver(t *testing.T) {
+	r := newSecretResolvers()
+	first, err := r.resolverFor("file")
+	if err != nil {
+		t.Fatalf("resolverFor failed: %v", err)
+	}
+	second, err := r.resolverFor("file")
+	if err != nil {
+		t.Fatalf("resolverFor failed: %v", err)
+	}
+	if first != second {
+		t.Error("expected the same cached resolver instance to be returned")
+	}
+}
+
+func TestResolveSecretRefsReplacesFileReference(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("resolved-value"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var cfg testLayerConfig
+	cfg.Host = "${file:" + path + "}"
+	cfg.Nested.Name = "literal-value"
+
+	r := newSecretResolvers()
+	if err := resolveSecretRefs(context.Background(), reflect.ValueOf(&cfg).Elem(), r); err != nil {
+		t.Fatalf("resolveSecretRefs failed: %v", err)
+	}
+
+	if cfg.Host != "resolved-value" {
+		t.Errorf("expected the file reference to be resolved, got %q", cfg.Host)
+	}
+	if cfg.Nested.Name != "literal-value" {
+		t.Errorf("expected a plain literal to be left untouched, got %q", cfg.Nested.Name)
+	}
+}
+
+func TestResolveSecretRefsPropagatesUnknownSchemeError(t *testing.T) {
+	var cfg testLayerConfig
+	cfg.Host = "${carrierpigeon:ref}"
+
+	r := newSecretResolvers()
+	if err := resolveSecretRefs(context.Background(), reflect.ValueOf(&cfg).Elem(), r); err == nil {
+		t.Error("expected an unknown secret scheme to fail resolution")
+	}
+}
+
+func TestVaultSecretResolverResolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/api" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"data":{"jwt":"vault-secret-value"}}}`))
+	}))
+	defer srv.Close()
+
+	v := &vaultSecretResolver{addr: srv.URL, client: srv.Client(), token: "test-token"}
+
+	got, err := v.Resolve(context.Background(), "secret/data/api#jwt")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if got != "vault-secret-value" {
+		t.Errorf("expected vault-secret-value, got %q", got)
+	}
+}
+
+func TestVaultSecretResolverResolveMissingKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"data":{"other":"value"}}}`))
+	}))
+	defer srv.Close()
+
+	v := &vaultSecretResolver{addr: srv.URL, client: srv.Client(), token: "test-token"}
+
+	if _, err := v.Resolve(context.Background(), "secret/data/api#jwt"); err == nil {
+		t.Error("expected resolving a key absent from the vault response to fail")
+	}
+}
+
+func TestVaultSecretResolverResolveMalformedRef(t *testing.T) {
+	v := &vaultSecretResolver{addr: "http://unused.invalid", client: http.DefaultClient}
+	if _, err := v.Resolve(context.Background(), "secret/data/api"); err == nil {
+		t.Error("expected a ref with no '#' to be rejected")
+	}
+}
+
+func TestVaultSecretResolverResolveErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	v := &vaultSecretResolver{addr: srv.URL, client: srv.Client(), token: "test-token"}
+	if _, err := v.Resolve(context.Background(), "secret/data/api#jwt"); err == nil {
+		t.Error("expected a non-2xx vault response to be surfaced as an error")
+	}
+}
+
+func TestSha256HexKnownVector(t *testing.T) {
+	// SHA-256 of the empty string is a well-known constant.
+	want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got := sha256Hex(nil); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestHmacSHA256MatchesStdlib(t *testing.T) {
+	key := []byte("a-signing-key")
+	data := "the string to sign"
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	want := mac.Sum(nil)
+
+	got := hmacSHA256(key, data)
+	if string(got) != string(want) {
+		t.Error("expected hmacSHA256 to match crypto/hmac's own output")
+	}
+}
+
+func TestSigV4KeyIsDeterministic(t *testing.T) {
+	k1 := sigV4Key("secret", "20250101", "us-east-1", "secretsmanager")
+	k2 := sigV4Key("secret", "20250101", "us-east-1", "secretsmanager")
+	if string(k1) != string(k2) {
+		t.Error("expected sigV4Key to be a pure function of its inputs")
+	}
+
+	k3 := sigV4Key("secret", "20250102", "us-east-1", "secretsmanager")
+	if string(k1) == string(k3) {
+		t.Error("expected sigV4Key to vary with the date stamp")
+	}
+}
+
+func TestSignSigV4SetsAuthorizationHeader(t *testing.T) {
+	a := &awssmSecretResolver{
+		region:          "us-east-1",
+		accessKeyID:     "AKIAEXAMPLE",
+		secretAccessKey: "secretkey",
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.signSigV4(req, "secretsmanager.us-east-1.amazonaws.com", []byte(`{}`), now)
+
+	auth := req.Header.Get("Authorization")
+	if auth == "" {
+		t.Fatal("expected signSigV4 to set an Authorization header")
+	}
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/20250101/us-east-1/secretsmanager/aws4_request"
+	if len(auth) < len(wantPrefix) || auth[:len(wantPrefix)] != wantPrefix {
+		t.Errorf("expected Authorization to start with %q, got %q", wantPrefix, auth)
+	}
+	if req.Header.Get("X-Amz-Date") != "20250101T000000Z" {
+		t.Errorf("expected X-Amz-Date 20250101T000000Z, got %q", req.Header.Get("X-Amz-Date"))
+	}
+}
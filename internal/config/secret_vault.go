@@ -0,0 +1,238 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/config/secret_vault.go
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	vaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	vaultMinRenewInterval    = 30 * time.Second
+)
+
+// vaultSecretResolver resolves "${vault:<path>#<key>}" references against a
+// Vault KV v2 mount, e.g. "secret/data/api#jwt" reads the "jwt" key at
+// secret/data/api. It authenticates once (token or Kubernetes auth); a
+// renewable Kubernetes-auth lease is renewed in the background until the
+// stop func runs, while a VAULT_TOKEN is used as-is and never renewed. It
+// does not cache the secret values themselves, since KV v2 reads
+// are cheap and this avoids ever serving a stale secret after a rotation.
+type vaultSecretResolver struct {
+	addr   string
+	client *http.Client
+
+	mu    sync.RWMutex
+	token string
+}
+
+// newVaultSecretResolver logs in to Vault (VAULT_ADDR) using VAULT_TOKEN if
+// set, falling back to Kubernetes auth (VAULT_ROLE + the pod's service
+// account JWT) otherwise, and returns the resolver along with a stop func
+// that ends the lease-renewal goroutine.
+func newVaultSecretResolver() (SecretResolver, func(), error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, nil, fmt.Errorf("config: vault secret referenced but VAULT_ADDR is not set")
+	}
+
+	v := &vaultSecretResolver{addr: strings.TrimRight(addr, "/"), client: &http.Client{Timeout: 10 * time.Second}}
+
+	var leaseDuration time.Duration
+	var renewable bool
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		v.token = token
+		// A directly supplied token's own TTL is whatever the operator
+		// issued it with; we have no lease to renew, so don't start the
+		// background goroutine.
+	} else {
+		token, dur, canRenew, err := v.loginKubernetes()
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: vault kubernetes auth: %w", err)
+		}
+		v.token = token
+		leaseDuration, renewable = dur, canRenew
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	if renewable && leaseDuration > 0 {
+		go v.renewLoop(leaseDuration, stop, done)
+	} else {
+		close(done)
+	}
+
+	return v, func() {
+		close(stop)
+		<-done
+	}, nil
+}
+
+// loginKubernetes authenticates via Vault's Kubernetes auth method
+// (auth/kubernetes/login), using VAULT_ROLE and the pod's projected service
+// account token.
+func (v *vaultSecretResolver) loginKubernetes() (token string, leaseDuration time.Duration, renewable bool, err error) {
+	role := os.Getenv("VAULT_ROLE")
+	if role == "" {
+		return "", 0, false, fmt.Errorf("neither VAULT_TOKEN nor VAULT_ROLE is set")
+	}
+	jwt, err := os.ReadFile(vaultKubernetesTokenPath)
+	if err != nil {
+		return "", 0, false, fmt.Errorf("reading service account token: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{"role": role, "jwt": strings.TrimSpace(string(jwt))})
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	var out struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+			Renewable     bool   `json:"renewable"`
+		} `json:"auth"`
+	}
+	if err := v.doJSON(context.Background(), http.MethodPost, "/v1/auth/kubernetes/login", body, "", &out); err != nil {
+		return "", 0, false, err
+	}
+	if out.Auth.ClientToken == "" {
+		return "", 0, false, fmt.Errorf("vault login response had no client_token")
+	}
+	return out.Auth.ClientToken, time.Duration(out.Auth.LeaseDuration) * time.Second, out.Auth.Renewable, nil
+}
+
+// renewLoop renews the current token's lease at roughly half its TTL until
+// stop is closed, logging (rather than failing the process) if a renewal
+// is ever rejected — the existing token keeps working until it actually
+// expires.
+func (v *vaultSecretResolver) renewLoop(leaseDuration time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	interval := leaseDuration / 2
+	if interval < vaultMinRenewInterval {
+		interval = vaultMinRenewInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := v.renewSelf(); err != nil {
+				log.Printf("config: vault: renewing token lease: %v", err)
+			}
+		}
+	}
+}
+
+func (v *vaultSecretResolver) renewSelf() error {
+	v.mu.RLock()
+	token := v.token
+	v.mu.RUnlock()
+
+	var out struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := v.doJSON(context.Background(), http.MethodPost, "/v1/auth/token/renew-self", nil, token, &out); err != nil {
+		return err
+	}
+	if out.Auth.ClientToken != "" {
+		v.mu.Lock()
+		v.token = out.Auth.ClientToken
+		v.mu.Unlock()
+	}
+	return nil
+}
+
+// Resolve reads ref, formatted "<kv-v2-data-path>#<key>", e.g.
+// "secret/data/api#jwt".
+func (v *vaultSecretResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("vault ref %q must be \"<path>#<key>\"", ref)
+	}
+
+	v.mu.RLock()
+	token := v.token
+	v.mu.RUnlock()
+
+	var out struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.doJSON(ctx, http.MethodGet, "/v1/"+path, nil, token, &out); err != nil {
+		return "", err
+	}
+	value, ok := out.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("vault path %q has no key %q", path, key)
+	}
+	return value, nil
+}
+
+func (v *vaultSecretResolver) doJSON(ctx context.Context, method, path string, body []byte, token string, out any) error {
+	u := v.addr + path
+	if _, err := url.Parse(u); err != nil {
+		return fmt.Errorf("invalid vault url %q: %w", u, err)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = strings.NewReader(string(body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("vault %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
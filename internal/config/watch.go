@@ -0,0 +1,167 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/config/watch.go
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadHooks are the typed, subsystem-scoped callbacks Watch invokes after
+// a reload passes ValidateReloadable — each only fires when the value it
+// covers actually changed, so e.g. editing DBPool settings doesn't also
+// poke the logger. All fields are optional.
+type ReloadHooks struct {
+	// LogLevel fires when Logger.Level changes.
+	LogLevel func(level string)
+	// MetricsEnabled fires when Metrics.Enabled changes.
+	MetricsEnabled func(enabled bool)
+	// DBPool fires when either Database.MaxOpenConns or
+	// Database.ConnMaxLifetime changes.
+	DBPool func(maxOpenConns int, connMaxLifetime time.Duration)
+}
+
+// ValidateReloadable rejects changes to whatever Watch considers too
+// dangerous to apply without a restart. ValidateReloadable is exported so
+// a caller can run it against a candidate Config before deciding to adopt
+// it, independent of Watch.
+func ValidateReloadable(old, next *Config) error {
+	if old.Server.Port != next.Server.Port {
+		return fmt.Errorf("server.port cannot change without a restart (%d -> %d)", old.Server.Port, next.Server.Port)
+	}
+	if old.Auth.JWTSecret != next.Auth.JWTSecret {
+		return fmt.Errorf("auth.jwt_secret cannot change without a restart")
+	}
+	return nil
+}
+
+// Watch watches c's source config file (set by Load from --config/-config
+// or CONFIG_FILE) for changes, re-loading and re-validating it on every
+// write. A reload that fails to parse, fails Validate, or fails
+// ValidateReloadable against the last-good Config is logged and skipped —
+// the previous Config keeps serving. A successful reload is published as a
+// new *Config snapshot on the returned channel rather than mutated in
+// place, since Config isn't safe to rewrite out from under a caller that
+// already holds a copy of one of its nested structs (e.g. cfg.Database
+// passed by value into database.Connect). hooks additionally lets a few
+// known subsystems react to just the field they care about.
+//
+// The returned channel is closed once Watch's background goroutine exits
+// (ctx done, or the underlying fsnotify watch failing); callers should
+// keep draining it until then.
+func (c *Config) Watch(ctx context.Context, hooks ReloadHooks) (<-chan *Config, error) {
+	if c.path == "" {
+		return nil, fmt.Errorf("config: Watch requires a config file (none was loaded via --config/-config or CONFIG_FILE)")
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("config: starting file watcher: %w", err)
+	}
+	if err := fsw.Add(c.path); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("config: watching %s: %w", c.path, err)
+	}
+
+	out := make(chan *Config, 1)
+	current := &atomic.Pointer[Config]{}
+	current.Store(c)
+
+	go func() {
+		defer close(out)
+		defer fsw.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("config: watch: %v", err)
+			case ev, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				prev := current.Load()
+				// Load panics on a value it can't convert to its field's
+				// type; treat that as a failed parse rather than a crash.
+				next, err := func() (cfg *Config, err error) {
+					defer func() {
+						if r := recover(); r != nil {
+							err = fmt.Errorf("%v", r)
+						}
+					}()
+					return Load()
+				}()
+				if err != nil {
+					log.Printf("config: reload of %s failed, keeping previous config: %v", c.path, err)
+					continue
+				}
+				if err := ValidateReloadable(prev, next); err != nil {
+					log.Printf("config: reload of %s rejected, keeping previous config: %v", c.path, err)
+					next.Close()
+					continue
+				}
+
+				current.Store(next)
+				prev.Close() // no longer reachable, stop its secret resolvers (e.g. Vault lease renewal)
+				dispatchReloadHooks(prev, next, hooks)
+
+				select {
+				case out <- next:
+				default:
+					// Drop-oldest: an unread previous snapshot is stale the
+					// moment a newer one lands.
+					select {
+					case <-out:
+					default:
+					}
+					out <- next
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// dispatchReloadHooks invokes whichever of hooks actually changed between
+// prev and next.
+func dispatchReloadHooks(prev, next *Config, hooks ReloadHooks) {
+	if hooks.LogLevel != nil && prev.Logger.Level != next.Logger.Level {
+		hooks.LogLevel(next.Logger.Level)
+	}
+	if hooks.MetricsEnabled != nil && prev.Metrics.Enabled != next.Metrics.Enabled {
+		hooks.MetricsEnabled(next.Metrics.Enabled)
+	}
+	if hooks.DBPool != nil && (prev.Database.MaxOpenConns != next.Database.MaxOpenConns || prev.Database.ConnMaxLifetime != next.Database.ConnMaxLifetime) {
+		hooks.DBPool(next.Database.MaxOpenConns, next.Database.ConnMaxLifetime)
+	}
+}
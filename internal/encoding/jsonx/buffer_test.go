@@ -0,0 +1,155 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamEncoderEncodeArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := NewStreamEncoder(w, 200)
+
+	err := enc.EncodeArray(func(enc *StreamEncoder) error {
+		for i := 1; i <= 3; i++ {
+			if err := enc.Encode(TestStruct{ID: i, Name: "item"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EncodeArray failed: %v", err)
+	}
+
+	if w.Code != 200 {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+
+	var got []TestStruct
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body isn't a valid JSON array: %v, body=%q", err, w.Body.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 elements, got %d: %+v", len(got), got)
+	}
+	for i, ts := range got {
+		if ts.ID != i+1 {
+			t.Errorf("element %d: got id %d", i, ts.ID)
+		}
+	}
+}
+
+func TestStreamEncoderEmptyArray(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := NewStreamEncoder(w, 200)
+
+	err := enc.EncodeArray(func(enc *StreamEncoder) error { return nil })
+	if err != nil {
+		t.Fatalf("EncodeArray failed: %v", err)
+	}
+	if w.Body.String() != "[]" {
+		t.Errorf("expected empty array body, got %q", w.Body.String())
+	}
+}
+
+func TestStreamEncoderSetsContentLengthBelowThreshold(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := NewStreamEncoder(w, 200)
+
+	if err := enc.EncodeArray(func(enc *StreamEncoder) error {
+		return enc.Encode(TestStruct{ID: 1, Name: "small"})
+	}); err != nil {
+		t.Fatalf("EncodeArray failed: %v", err)
+	}
+
+	if w.Header().Get("Content-Length") == "" {
+		t.Error("expected Content-Length for a payload under streamChunkThreshold")
+	}
+}
+
+func TestStreamEncoderDropsContentLengthAboveThreshold(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := NewStreamEncoder(w, 200)
+
+	big := string(make([]byte, streamChunkThreshold))
+	err := enc.EncodeArray(func(enc *StreamEncoder) error {
+		return enc.Encode(TestStruct{ID: 1, Name: big})
+	})
+	if err != nil {
+		t.Fatalf("EncodeArray failed: %v", err)
+	}
+
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("expected no Content-Length once the payload crosses streamChunkThreshold")
+	}
+
+	var got []TestStruct
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body isn't a valid JSON array: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestStreamEncoderPropagatesEncodeError(t *testing.T) {
+	w := httptest.NewRecorder()
+	enc := NewStreamEncoder(w, 200)
+
+	boom := func(enc *StreamEncoder) error {
+		return enc.Encode(make(chan int)) // not JSON-encodable
+	}
+	if err := enc.EncodeArray(boom); err == nil {
+		t.Error("expected EncodeArray to surface the marshal error")
+	}
+}
+
+// streamFixture builds n TestStructs for the benchmarks below.
+func streamFixture(n int) []TestStruct {
+	out := make([]TestStruct, n)
+	for i := range out {
+		out[i] = TestStruct{ID: i, Name: "benchmark-record"}
+	}
+	return out
+}
+
+const streamBenchRecords = 100_000
+
+// BenchmarkStreamEncoderEncodeArray measures StreamEncoder.EncodeArray
+// streaming element-at-a-time, the way newStreamUsersHandler consumes a
+// repository cursor - memory use shouldn't grow with streamBenchRecords.
+func BenchmarkStreamEncoderEncodeArray(b *testing.B) {
+	records := streamFixture(streamBenchRecords)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		enc := NewStreamEncoder(w, 200)
+		_ = enc.EncodeArray(func(enc *StreamEncoder) error {
+			for _, r := range records {
+				if err := enc.Encode(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// BenchmarkBufferedMarshalToBuffer measures the buffered path
+// newListUsersHandler still uses for a full-memory comparison: the whole
+// slice is marshaled into one pooled buffer before anything is written.
+func BenchmarkBufferedMarshalToBuffer(b *testing.B) {
+	records := streamFixture(streamBenchRecords)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := &bytes.Buffer{}
+		_ = MarshalToBuffer(records, buf)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/encoding/jsonx/line.go
+package jsonx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// scratchPool holds the []byte buffers LineDecoder.Decode reads each line
+// into, so a million-row ingest doesn't allocate one per row.
+var scratchPool = sync.Pool{
+	New: func() any { b := make([]byte, 0, 4096); return &b },
+}
+
+// LineDecoder reads newline-delimited JSON (NDJSON), one value per line,
+// blank lines skipped. Unlike json.Decoder it never needs to buffer ahead
+// looking for the end of a value, since the newline already delimits it.
+type LineDecoder struct {
+	r *bufio.Reader
+}
+
+// NewLineDecoder wraps r in a buffered reader sized for NDJSON ingest.
+func NewLineDecoder(r io.Reader) *LineDecoder {
+	return &LineDecoder{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Decode reads the next non-blank line and unmarshals it into v. It
+// returns io.EOF once the stream is exhausted, matching json.Decoder's
+// contract so callers can loop with `for { if err := dec.Decode(v); err ==
+// io.EOF { break } }`.
+func (d *LineDecoder) Decode(v any) error {
+	scratch := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratch)
+
+	for {
+		line, err := d.readLine(scratch)
+		if len(line) == 0 {
+			if err != nil {
+				return err
+			}
+			continue // blank line
+		}
+		// A final line with no trailing newline still decodes successfully
+		// here; err (io.EOF) is deliberately swallowed so the caller gets
+		// this record now and io.EOF on its next call, not both at once.
+		return json.Unmarshal(line, v)
+	}
+}
+
+// readLine reads up to the next '\n' (or EOF) into *scratch, reusing its
+// backing array across calls, and returns the line with any trailing
+// '\r\n'/'\n' and surrounding whitespace trimmed.
+func (d *LineDecoder) readLine(scratch *[]byte) ([]byte, error) {
+	*scratch = (*scratch)[:0]
+	for {
+		chunk, err := d.r.ReadSlice('\n')
+		*scratch = append(*scratch, chunk...)
+		if err == nil {
+			break
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		// io.EOF (or another read error) with whatever partial line we have.
+		return bytes.TrimSpace(*scratch), err
+	}
+	return bytes.TrimSpace(*scratch), nil
+}
+
+// LineEncoder writes values as newline-delimited JSON, one per line, ready
+// for streaming bulk exports.
+type LineEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewLineEncoder wraps w in a buffered writer and disables HTML escaping,
+// matching MarshalToBuffer's convention.
+func NewLineEncoder(w io.Writer) *LineEncoder {
+	bw := bufio.NewWriterSize(w, 64*1024)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &LineEncoder{w: bw, enc: enc}
+}
+
+// Encode marshals v and writes it followed by a trailing newline.
+// json.Encoder.Encode already appends one, so this is just a thin,
+// named wrapper to keep call sites symmetric with LineDecoder.Decode.
+func (e *LineEncoder) Encode(v any) error {
+	return e.enc.Encode(v)
+}
+
+// Flush writes any buffered bytes through to the underlying io.Writer.
+// Callers must call this once they're done encoding, since LineEncoder
+// buffers internally.
+func (e *LineEncoder) Flush() error {
+	return e.w.Flush()
+}
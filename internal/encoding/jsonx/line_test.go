@@ -0,0 +1,211 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineDecoderDecodesEachLine(t *testing.T) {
+	r := strings.NewReader("{\"id\":1,\"name\":\"first\"}\n{\"id\":2,\"name\":\"second\"}\n")
+	dec := NewLineDecoder(r)
+
+	var got []TestStruct
+	for {
+		var ts TestStruct
+		if err := dec.Decode(&ts); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Decode failed: %v", err)
+		}
+		got = append(got, ts)
+	}
+
+	want := []TestStruct{{ID: 1, Name: "first"}, {ID: 2, Name: "second"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLineDecoderSkipsBlankLines(t *testing.T) {
+	r := strings.NewReader("\n{\"id\":1,\"name\":\"first\"}\n\n\n{\"id\":2,\"name\":\"second\"}\n\n")
+	dec := NewLineDecoder(r)
+
+	count := 0
+	for {
+		var ts TestStruct
+		if err := dec.Decode(&ts); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("Decode failed: %v", err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 records, got %d", count)
+	}
+}
+
+func TestLineDecoderNoTrailingNewline(t *testing.T) {
+	r := strings.NewReader(`{"id":1,"name":"first"}`)
+	dec := NewLineDecoder(r)
+
+	var ts TestStruct
+	if err := dec.Decode(&ts); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if ts != (TestStruct{ID: 1, Name: "first"}) {
+		t.Errorf("got %+v", ts)
+	}
+
+	if err := dec.Decode(&ts); err != io.EOF {
+		t.Errorf("expected io.EOF on next call, got %v", err)
+	}
+}
+
+func TestLineDecoderEmptyInput(t *testing.T) {
+	dec := NewLineDecoder(strings.NewReader(""))
+	var ts TestStruct
+	if err := dec.Decode(&ts); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineDecoderInvalidJSON(t *testing.T) {
+	dec := NewLineDecoder(strings.NewReader("not json\n"))
+	var ts TestStruct
+	err := dec.Decode(&ts)
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("expected a JSON error, got %v", err)
+	}
+}
+
+func TestLineEncoderWritesNewlineDelimited(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := NewLineEncoder(buf)
+
+	if err := enc.Encode(TestStruct{ID: 1, Name: "first"}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Encode(TestStruct{ID: 2, Name: "second"}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var ts TestStruct
+	if err := json.Unmarshal([]byte(lines[0]), &ts); err != nil || ts.ID != 1 {
+		t.Errorf("line 1 = %q, unmarshal error %v", lines[0], err)
+	}
+}
+
+func TestLineEncoderEscapeHTML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := NewLineEncoder(buf)
+
+	if err := enc.Encode(TestStruct{ID: 1, Name: "<script>alert('xss')</script>"}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "<script>") {
+		t.Errorf("expected HTML not to be escaped, got %q", buf.String())
+	}
+}
+
+func TestLineEncoderDecoderRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := NewLineEncoder(buf)
+	for i := 0; i < 1000; i++ {
+		if err := enc.Encode(TestStruct{ID: i, Name: "record"}); err != nil {
+			t.Fatalf("Encode failed at %d: %v", i, err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	dec := NewLineDecoder(buf)
+	for i := 0; i < 1000; i++ {
+		var ts TestStruct
+		if err := dec.Decode(&ts); err != nil {
+			t.Fatalf("Decode failed at %d: %v", i, err)
+		}
+		if ts.ID != i {
+			t.Errorf("record %d: got id %d", i, ts.ID)
+		}
+	}
+	var ts TestStruct
+	if err := dec.Decode(&ts); err != io.EOF {
+		t.Errorf("expected io.EOF after last record, got %v", err)
+	}
+}
+
+// ndjsonFixture builds n newline-delimited JSON records for the benchmarks
+// below.
+func ndjsonFixture(n int) []byte {
+	buf := &bytes.Buffer{}
+	enc := NewLineEncoder(buf)
+	for i := 0; i < n; i++ {
+		_ = enc.Encode(TestStruct{ID: i, Name: "benchmark-record"})
+	}
+	_ = enc.Flush()
+	return buf.Bytes()
+}
+
+const ndjsonBenchRecords = 100_000
+
+func BenchmarkLineDecoder100k(b *testing.B) {
+	fixture := ndjsonFixture(ndjsonBenchRecords)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dec := NewLineDecoder(bytes.NewReader(fixture))
+		var ts TestStruct
+		for {
+			if err := dec.Decode(&ts); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkJSONDecoder100k(b *testing.B) {
+	fixture := ndjsonFixture(ndjsonBenchRecords)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dec := json.NewDecoder(bytes.NewReader(fixture))
+		var ts TestStruct
+		for {
+			if err := dec.Decode(&ts); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkLineEncoder100k(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := &bytes.Buffer{}
+		enc := NewLineEncoder(buf)
+		for j := 0; j < ndjsonBenchRecords; j++ {
+			_ = enc.Encode(TestStruct{ID: j, Name: "benchmark-record"})
+		}
+		_ = enc.Flush()
+	}
+}
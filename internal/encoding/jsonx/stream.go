@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/encoding/jsonx/stream.go
+package jsonx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// streamChunkThreshold is how many bytes StreamEncoder buffers before
+// giving up on a Content-Length response: below it, a small array still
+// gets sized exactly like MarshalToBuffer; past it, buffering the rest of
+// a possibly-unbounded cursor just to measure it isn't worth it, so
+// StreamEncoder flushes what it has straight to the http.ResponseWriter
+// and keeps writing without ever setting Content-Length - which makes
+// net/http fall back to chunked transfer encoding for the remainder.
+const streamChunkThreshold = 64 * 1024
+
+// streamBufMinSize floors the size of a pooled bufio.Writer created from a
+// GetBuffer buffer that hasn't grown yet (a fresh *bytes.Buffer's Cap() is
+// 0), so the first flush of any StreamEncoder still gets a useful buffer.
+const streamBufMinSize = 4096
+
+// bufioWriterPool holds *bufio.Writer instances sized off jsonx's own
+// buffer pool, reused across StreamEncoder.EncodeArray calls once a
+// payload crosses streamChunkThreshold and needs to write straight to the
+// response instead of staying in the staging buffer.
+var bufioWriterPool = sync.Pool{
+	New: func() any {
+		buf := GetBuffer()
+		size := buf.Cap()
+		PutBuffer(buf)
+		if size < streamBufMinSize {
+			size = streamBufMinSize
+		}
+		return bufio.NewWriterSize(io.Discard, size)
+	},
+}
+
+// StreamEncoder writes a JSON array one element at a time - from a
+// repository cursor, via EncodeArray - instead of marshaling the whole
+// slice into memory first. Arrays that stay under streamChunkThreshold
+// still get a normal, Content-Length-bearing response; larger ones fall
+// back to chunked transfer encoding, so memory use stays flat no matter
+// how many elements the cursor yields.
+type StreamEncoder struct {
+	w      http.ResponseWriter
+	status int
+
+	buf *bytes.Buffer // staging buffer, nil once flushed past the threshold
+	bw  *bufio.Writer // pooled, wraps w directly once buf is flushed
+
+	scratch *bytes.Buffer // one element's encoded JSON, reused every Encode call
+	enc     *json.Encoder // targets scratch
+
+	wrote int // elements encoded so far, for comma placement
+}
+
+// NewStreamEncoder creates a StreamEncoder that will write status and
+// "application/json" for a JSON array once EncodeArray starts emitting
+// elements.
+func NewStreamEncoder(w http.ResponseWriter, status int) *StreamEncoder {
+	scratch := GetBuffer()
+	enc := json.NewEncoder(scratch)
+	enc.SetEscapeHTML(false)
+
+	return &StreamEncoder{
+		w:       w,
+		status:  status,
+		buf:     GetBuffer(),
+		scratch: scratch,
+		enc:     enc,
+	}
+}
+
+// EncodeArray writes the opening '[', calls emit once so it can push each
+// element through Encode - typically looping over a repository cursor -
+// writes the closing ']', and flushes. The StreamEncoder must not be used
+// again after EncodeArray returns.
+func (se *StreamEncoder) EncodeArray(emit func(enc *StreamEncoder) error) error {
+	defer se.release()
+
+	if err := se.write([]byte{'['}); err != nil {
+		return err
+	}
+	if err := emit(se); err != nil {
+		return err
+	}
+	if err := se.write([]byte{']'}); err != nil {
+		return err
+	}
+	return se.finish()
+}
+
+// Encode writes v as the next array element, separated from the previous
+// one by a comma. Only valid from inside the EncodeArray callback.
+func (se *StreamEncoder) Encode(v any) error {
+	if se.wrote > 0 {
+		if err := se.write([]byte{','}); err != nil {
+			return err
+		}
+	}
+	se.wrote++
+
+	se.scratch.Reset()
+	if err := se.enc.Encode(v); err != nil {
+		return err
+	}
+	// json.Encoder.Encode appends a trailing newline; the array syntax
+	// supplies its own separators, so drop it.
+	return se.write(bytes.TrimRight(se.scratch.Bytes(), "\n"))
+}
+
+// write appends p to the staging buffer until it crosses
+// streamChunkThreshold, at which point it flushes everything buffered so
+// far straight to w - dropping Content-Length so net/http switches to
+// chunked transfer encoding - and routes every subsequent write through a
+// pooled bufio.Writer wrapping w directly.
+func (se *StreamEncoder) write(p []byte) error {
+	if se.bw != nil {
+		_, err := se.bw.Write(p)
+		return err
+	}
+
+	se.buf.Write(p)
+	if se.buf.Len() <= streamChunkThreshold {
+		return nil
+	}
+
+	se.w.Header().Set("Content-Type", "application/json")
+	se.w.WriteHeader(se.status)
+
+	bw := bufioWriterPool.Get().(*bufio.Writer)
+	bw.Reset(se.w)
+	if _, err := bw.Write(se.buf.Bytes()); err != nil {
+		bw.Reset(io.Discard)
+		bufioWriterPool.Put(bw)
+		return err
+	}
+
+	PutBuffer(se.buf)
+	se.buf = nil
+	se.bw = bw
+	return nil
+}
+
+// finish flushes whatever's left: a buffered payload that never crossed
+// streamChunkThreshold gets a normal Content-Length response, one that did
+// just needs its pooled bufio.Writer flushed.
+func (se *StreamEncoder) finish() error {
+	if se.bw != nil {
+		return se.bw.Flush()
+	}
+
+	se.w.Header().Set("Content-Type", "application/json")
+	se.w.Header().Set("Content-Length", strconv.Itoa(se.buf.Len()))
+	se.w.WriteHeader(se.status)
+	_, err := se.w.Write(se.buf.Bytes())
+	return err
+}
+
+// release returns every pooled buffer/writer StreamEncoder is holding.
+// Safe to call more than once.
+func (se *StreamEncoder) release() {
+	if se.buf != nil {
+		PutBuffer(se.buf)
+		se.buf = nil
+	}
+	if se.scratch != nil {
+		PutBuffer(se.scratch)
+		se.scratch = nil
+	}
+	if se.bw != nil {
+		se.bw.Reset(io.Discard)
+		bufioWriterPool.Put(se.bw)
+		se.bw = nil
+	}
+}
+
+// WriteJSON sets the Content-Type header, writes status, and streams v
+// straight to w via a bare json.Encoder with HTML-escaping disabled, same
+// as MarshalToBuffer - it never buffers the payload, so there's no
+// Content-Length to set and no allocation proportional to the response
+// size, at the cost of not being able to turn a marshal error into a
+// clean error response once bytes are already on the wire. Use this for a
+// single value; for a large or open-ended array (e.g. streamed from a
+// repository cursor) use StreamEncoder.EncodeArray instead, which keeps
+// memory flat regardless of element count.
+func WriteJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
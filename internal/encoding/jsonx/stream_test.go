@@ -0,0 +1,60 @@
+package jsonx
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	ts := TestStruct{ID: 123, Name: "test"}
+
+	err := WriteJSON(w, 201, ts)
+	if err != nil {
+		t.Errorf("WriteJSON failed: %v", err)
+	}
+
+	if w.Code != 201 {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %q", ct)
+	}
+
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("WriteJSON should not set Content-Length, response isn't buffered")
+	}
+
+	var result TestStruct
+	if err := NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if result != ts {
+		t.Errorf("Expected %+v, got %+v", ts, result)
+	}
+}
+
+func TestWriteJSONEscapeHTML(t *testing.T) {
+	w := httptest.NewRecorder()
+	ts := TestStruct{ID: 1, Name: "<script>alert('xss')</script>"}
+
+	if err := WriteJSON(w, 200, ts); err != nil {
+		t.Errorf("WriteJSON failed: %v", err)
+	}
+
+	if !strings.Contains(w.Body.String(), "<script>") {
+		t.Errorf("Expected HTML not to be escaped, got %q", w.Body.String())
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	ts := TestStruct{ID: 123, Name: "test"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		_ = WriteJSON(w, 200, ts)
+	}
+}
@@ -29,6 +29,9 @@ type APIError struct {
 	Message    string      `json:"message"`
 	Details    interface{} `json:"details,omitempty"`
 	StatusCode int         `json:"-"`
+	// Stack is the call stack at the point the error was created, for
+	// logging only - it's never serialized in the API response.
+	Stack []string `json:"-"`
 }
 
 func (e APIError) Error() string {
@@ -37,35 +40,39 @@ func (e APIError) Error() string {
 
 // Common error codes
 const (
-	CodeInternal        = "INTERNAL_ERROR"
-	CodeBadRequest      = "BAD_REQUEST"
-	CodeUnauthorized    = "UNAUTHORIZED"
-	CodeForbidden       = "FORBIDDEN"
-	CodeNotFound        = "NOT_FOUND"
-	CodeConflict        = "CONFLICT"
-	CodeValidation      = "VALIDATION_ERROR"
-	CodeTooManyRequests = "TOO_MANY_REQUESTS"
-	CodeTimeout         = "TIMEOUT"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeConflict           = "CONFLICT"
+	CodeValidation         = "VALIDATION_ERROR"
+	CodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	CodeTimeout            = "TIMEOUT"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
 )
 
 // Predefined errors
 var (
-	ErrInternal        = NewAPIError(CodeInternal, "Internal server error", http.StatusInternalServerError)
-	ErrBadRequest      = NewAPIError(CodeBadRequest, "Bad request", http.StatusBadRequest)
-	ErrUnauthorized    = NewAPIError(CodeUnauthorized, "Unauthorized", http.StatusUnauthorized)
-	ErrForbidden       = NewAPIError(CodeForbidden, "Forbidden", http.StatusForbidden)
-	ErrNotFound        = NewAPIError(CodeNotFound, "Resource not found", http.StatusNotFound)
-	ErrConflict        = NewAPIError(CodeConflict, "Resource conflict", http.StatusConflict)
-	ErrTooManyRequests = NewAPIError(CodeTooManyRequests, "Too many requests", http.StatusTooManyRequests)
-	ErrTimeout         = NewAPIError(CodeTimeout, "Request timeout", http.StatusGatewayTimeout)
+	ErrInternal           = NewAPIError(CodeInternal, "Internal server error", http.StatusInternalServerError)
+	ErrBadRequest         = NewAPIError(CodeBadRequest, "Bad request", http.StatusBadRequest)
+	ErrUnauthorized       = NewAPIError(CodeUnauthorized, "Unauthorized", http.StatusUnauthorized)
+	ErrForbidden          = NewAPIError(CodeForbidden, "Forbidden", http.StatusForbidden)
+	ErrNotFound           = NewAPIError(CodeNotFound, "Resource not found", http.StatusNotFound)
+	ErrConflict           = NewAPIError(CodeConflict, "Resource conflict", http.StatusConflict)
+	ErrTooManyRequests    = NewAPIError(CodeTooManyRequests, "Too many requests", http.StatusTooManyRequests)
+	ErrTimeout            = NewAPIError(CodeTimeout, "Request timeout", http.StatusGatewayTimeout)
+	ErrServiceUnavailable = NewAPIError(CodeServiceUnavailable, "Service unavailable", http.StatusServiceUnavailable)
 )
 
-// NewAPIError creates a new API error
+// NewAPIError creates a new API error, capturing the call stack at the
+// point of creation.
 func NewAPIError(code, message string, statusCode int) *APIError {
 	return &APIError{
 		Code:       code,
 		Message:    message,
 		StatusCode: statusCode,
+		Stack:      captureStack(1),
 	}
 }
 
@@ -76,6 +83,7 @@ func (e *APIError) WithDetails(details interface{}) *APIError {
 		Message:    e.Message,
 		Details:    details,
 		StatusCode: e.StatusCode,
+		Stack:      e.Stack,
 	}
 }
 
@@ -86,6 +94,7 @@ func (e *APIError) WithMessage(message string) *APIError {
 		Message:    message,
 		Details:    e.Details,
 		StatusCode: e.StatusCode,
+		Stack:      e.Stack,
 	}
 }
 
@@ -96,6 +105,7 @@ func ValidationError(details interface{}) *APIError {
 		Message:    "Validation failed",
 		Details:    details,
 		StatusCode: http.StatusBadRequest,
+		Stack:      captureStack(1),
 	}
 }
 
@@ -106,6 +116,7 @@ func UserNotFoundError(userID interface{}) *APIError {
 		Message:    fmt.Sprintf("User not found"),
 		Details:    map[string]interface{}{"user_id": userID},
 		StatusCode: http.StatusNotFound,
+		Stack:      captureStack(1),
 	}
 }
 
@@ -116,6 +127,7 @@ func UserAlreadyExistsError(email string) *APIError {
 		Message:    "User already exists",
 		Details:    map[string]interface{}{"email": email},
 		StatusCode: http.StatusConflict,
+		Stack:      captureStack(1),
 	}
 }
 
@@ -125,6 +137,7 @@ func InvalidCredentialsError() *APIError {
 		Code:       CodeUnauthorized,
 		Message:    "Invalid credentials",
 		StatusCode: http.StatusUnauthorized,
+		Stack:      captureStack(1),
 	}
 }
 
@@ -135,6 +148,7 @@ func InsufficientPermissionsError(permission string) *APIError {
 		Message:    "Insufficient permissions",
 		Details:    map[string]interface{}{"required_permission": permission},
 		StatusCode: http.StatusForbidden,
+		Stack:      captureStack(1),
 	}
 }
 
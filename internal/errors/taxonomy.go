@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/errors/taxonomy.go
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// Additional error codes beyond the original set, so that every gRPC status
+// other than OK and Unknown has a code mapping to it in codeMapping below.
+const (
+	CodeCanceled           = "CANCELED"
+	CodeAlreadyExists      = "ALREADY_EXISTS"
+	CodeResourceExhausted  = "RESOURCE_EXHAUSTED"
+	CodeFailedPrecondition = "FAILED_PRECONDITION"
+	CodeAborted            = "ABORTED"
+	CodeOutOfRange         = "OUT_OF_RANGE"
+	CodeUnimplemented      = "UNIMPLEMENTED"
+	CodeUnavailable        = "UNAVAILABLE"
+	CodeDataLoss           = "DATA_LOSS"
+)
+
+// GRPCCode mirrors google.golang.org/grpc/codes.Code. It's duplicated here,
+// numeric value for numeric value, instead of importing grpc, so that
+// pulling in this package doesn't pull in a gRPC dependency for services
+// that only ever speak HTTP. A gRPC server can cast this straight to
+// codes.Code.
+type GRPCCode uint32
+
+const (
+	GRPCOk GRPCCode = iota
+	GRPCCanceled
+	GRPCUnknown
+	GRPCInvalidArgument
+	GRPCDeadlineExceeded
+	GRPCNotFound
+	GRPCAlreadyExists
+	GRPCPermissionDenied
+	GRPCResourceExhausted
+	GRPCFailedPrecondition
+	GRPCAborted
+	GRPCOutOfRange
+	GRPCUnimplemented
+	GRPCInternal
+	GRPCUnavailable
+	GRPCDataLoss
+	GRPCUnauthenticated
+)
+
+// codeMapping is the single source of truth for how a Code translates to
+// each transport: every code an HTTP handler or a gRPC service can return
+// has exactly one row here, so adding a transport never means re-deriving
+// this table by hand somewhere else.
+var codeMapping = map[string]struct {
+	http int
+	grpc GRPCCode
+}{
+	CodeInternal:           {http.StatusInternalServerError, GRPCInternal},
+	CodeBadRequest:         {http.StatusBadRequest, GRPCInvalidArgument},
+	CodeUnauthorized:       {http.StatusUnauthorized, GRPCUnauthenticated},
+	CodeForbidden:          {http.StatusForbidden, GRPCPermissionDenied},
+	CodeNotFound:           {http.StatusNotFound, GRPCNotFound},
+	CodeConflict:           {http.StatusConflict, GRPCAlreadyExists},
+	CodeValidation:         {http.StatusBadRequest, GRPCInvalidArgument},
+	CodeTooManyRequests:    {http.StatusTooManyRequests, GRPCResourceExhausted},
+	CodeTimeout:            {http.StatusGatewayTimeout, GRPCDeadlineExceeded},
+	CodeServiceUnavailable: {http.StatusServiceUnavailable, GRPCUnavailable},
+	CodeCanceled:           {http.StatusRequestTimeout, GRPCCanceled},
+	CodeAlreadyExists:      {http.StatusConflict, GRPCAlreadyExists},
+	CodeResourceExhausted:  {http.StatusTooManyRequests, GRPCResourceExhausted},
+	CodeFailedPrecondition: {http.StatusPreconditionFailed, GRPCFailedPrecondition},
+	CodeAborted:            {http.StatusConflict, GRPCAborted},
+	CodeOutOfRange:         {http.StatusBadRequest, GRPCOutOfRange},
+	CodeUnimplemented:      {http.StatusNotImplemented, GRPCUnimplemented},
+	CodeUnavailable:        {http.StatusServiceUnavailable, GRPCUnavailable},
+	CodeDataLoss:           {http.StatusInternalServerError, GRPCDataLoss},
+}
+
+// GRPCCode returns the gRPC status code a service should return for e,
+// falling back to GRPCUnknown for a code this package doesn't recognize.
+func (e *APIError) GRPCCode() GRPCCode {
+	if m, ok := codeMapping[e.Code]; ok {
+		return m.grpc
+	}
+	return GRPCUnknown
+}
+
+// httpStatusForCode looks up the canonical HTTP status for code, falling
+// back to 500 for anything not in codeMapping (e.g. a caller-defined code).
+func httpStatusForCode(code string) int {
+	if m, ok := codeMapping[code]; ok {
+		return m.http
+	}
+	return http.StatusInternalServerError
+}
+
+// maxStackFrames bounds how deep captureStack walks, so a deeply recursive
+// caller doesn't turn every error into a multi-KB payload.
+const maxStackFrames = 32
+
+// captureStack records the call stack at the point an APIError was created,
+// skipping the captureStack/NewAPIError frames themselves. It's attached to
+// Stack rather than formatted into Message so logging it is opt-in - the
+// JSON response sent to clients never includes it.
+func captureStack(skip int) []string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(skip+2, pcs)
+	if n == 0 {
+		return nil
+	}
+
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]string, 0, n)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, fmt.Sprintf("%s:%d %s", frame.File, frame.Line, frame.Function))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/handlers/oidc.go
+package handlers
+
+import (
+	"net/http"
+
+	"highperf-api/internal/auth"
+	"highperf-api/internal/encoding/jsonx"
+	"highperf-api/internal/repository"
+)
+
+// NewOIDCIdentityHandler builds the httpserver.OIDCIdentityHandler called
+// once an OIDC callback's ID token has been verified: it upserts the local
+// user for the external identity and mints a TokenPair, the same way
+// NewOAuthIdentityHandler does for the connectors flow.
+func NewOIDCIdentityHandler(repo repository.UserRepository, jwtService *auth.JWTService) func(w http.ResponseWriter, r *http.Request, provider string, claims *auth.IDClaims) {
+	return func(w http.ResponseWriter, r *http.Request, provider string, claims *auth.IDClaims) {
+		user, err := repo.UpsertFromIdentity(r.Context(), provider, claims.Subject, claims.Email, "")
+		if err != nil {
+			http.Error(w, "failed to resolve external identity", http.StatusInternalServerError)
+			return
+		}
+
+		tokens, err := jwtService.GenerateTokenPair(user.ID, user.Email)
+		if err != nil {
+			http.Error(w, "failed to mint tokens", http.StatusInternalServerError)
+			return
+		}
+
+		buf := jsonx.GetBuffer()
+		defer jsonx.PutBuffer(buf)
+
+		if err := jsonx.MarshalToBuffer(tokens, buf); err != nil {
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(buf.Bytes())
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/handlers/ready.go
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// ready starts false, flips to true once the process has finished starting
+// up, and flips back to false as soon as graceful shutdown begins, so a load
+// balancer stops sending new traffic before Healthz would ever notice.
+var ready atomic.Bool
+
+// SetReady updates the process-wide readiness flag consulted by Readyz.
+func SetReady(v bool) {
+	ready.Store(v)
+}
+
+// Readyz is the manejador for GET /readyz: unlike Healthz (always 200, used
+// for liveness), this reports whether the process is currently accepting
+// new work.
+func Readyz(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
@@ -20,7 +20,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
@@ -124,11 +123,9 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request, _ httpr
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	// Escribimos la respuesta del usuario creado directamente al writer
-	enc := json.NewEncoder(w)
-	enc.Encode(createdUser.ToResponse())
+	// Respuesta directa al writer, sin buffer: el usuario creado es chico
+	// y no hace falta Content-Length para esto.
+	_ = jsonx.WriteJSON(w, http.StatusCreated, createdUser.ToResponse())
 }
 
 // ServeStatic sirve archivos est√°ticos.
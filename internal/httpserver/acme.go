@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/acme.go
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const letsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// Cache is autocert's certificate cache contract. Re-exported here so
+// callers implementing a custom store (S3, Redis, ...) don't need to
+// import golang.org/x/crypto/acme/autocert themselves.
+type Cache = autocert.Cache
+
+// TLSConfig enables automatically-provisioned, auto-renewing Let's Encrypt
+// certificates in place of an external TLS terminator.
+type TLSConfig struct {
+	// Enabled turns ACME mode on. When false, callers should fall back to
+	// their own static certificate / plain HTTP setup.
+	Enabled bool
+	// Domains is the whitelist of hostnames autocert is allowed to issue
+	// certificates for; issuance for any other Host is refused.
+	Domains []string
+	// Email is passed to the ACME account registration, used by the CA for
+	// renewal/revocation notices.
+	Email string
+	// CacheDir is where certificates are persisted when Cache is nil.
+	CacheDir string
+	// Staging points the manager at Let's Encrypt's staging directory,
+	// which issues untrusted certificates but isn't subject to the
+	// production rate limits, so it belongs in every non-prod config.
+	Staging bool
+	// Cache overrides the on-disk cache, e.g. with an S3- or Redis-backed
+	// implementation so certificates are shared across instances instead
+	// of each one re-issuing its own.
+	Cache Cache
+}
+
+// NewACMEManager builds an autocert.Manager from cfg.
+func NewACMEManager(cfg TLSConfig) *autocert.Manager {
+	cache := cfg.Cache
+	if cache == nil {
+		cache = autocert.DirCache(cfg.CacheDir)
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      cache,
+		Email:      cfg.Email,
+	}
+
+	if cfg.Staging {
+		m.Client = &acme.Client{DirectoryURL: letsEncryptStagingURL}
+	}
+
+	return m
+}
+
+// ListenAndServeACME runs the API behind ACME-issued TLS: the `http-01`
+// challenge is served on :80 via manager.HTTPHandler, and handler (the full
+// withServerHeader/withRecover/.../withTracing middleware chain) is served
+// over TLS on :443 using certificates the manager provisions and renews.
+// It blocks until the HTTPS listener returns, which happens on Shutdown or
+// a fatal listen error; the :80 challenge listener is stopped on return.
+func ListenAndServeACME(ctx context.Context, cfg TLSConfig, handler http.Handler) error {
+	manager := NewACMEManager(cfg)
+
+	challengeSrv := &http.Server{
+		Addr:    ":80",
+		Handler: manager.HTTPHandler(nil),
+	}
+	go func() {
+		_ = challengeSrv.ListenAndServe()
+	}()
+	defer challengeSrv.Shutdown(ctx)
+
+	tlsSrv := &http.Server{
+		Addr:      ":443",
+		Handler:   handler,
+		TLSConfig: manager.TLSConfig(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = tlsSrv.Shutdown(context.Background())
+	}()
+
+	if err := tlsSrv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("acme https listener failed: %w", err)
+	}
+	return nil
+}
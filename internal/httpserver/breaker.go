@@ -0,0 +1,355 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/breaker.go
+package httpserver
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BreakerState is one of the three states of a CircuitBreaker.
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker's trip and recovery behavior.
+type BreakerConfig struct {
+	// Window is the rolling window over which requests are counted.
+	Window time.Duration
+	// MinRequests is the minimum number of requests that must have been
+	// observed within Window before the failure ratio is evaluated, so a
+	// single failed request on a quiet route doesn't trip the breaker.
+	MinRequests int
+	// FailureRatio is the fraction of requests in Window that must fail to
+	// trip the breaker, e.g. 0.5 for 50%.
+	FailureRatio float64
+	// OpenFor is the initial cool-down after tripping, before the breaker
+	// moves to Half-Open. Repeated trips back-off this duration
+	// exponentially, capped at MaxOpenFor.
+	OpenFor time.Duration
+	// MaxOpenFor caps the exponential back-off of OpenFor. Zero means the
+	// back-off is uncapped.
+	MaxOpenFor time.Duration
+	// HalfOpenMaxProbes is how many concurrent requests are allowed through
+	// while Half-Open. Default 1 when zero.
+	HalfOpenMaxProbes int
+}
+
+// DefaultBreakerConfig matches the defaults requested for the API: 20
+// requests / 50% failures / 10s window, with a single half-open probe.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:            10 * time.Second,
+		MinRequests:       20,
+		FailureRatio:      0.5,
+		OpenFor:           2 * time.Second,
+		MaxOpenFor:        2 * time.Minute,
+		HalfOpenMaxProbes: 1,
+	}
+}
+
+// KeyFunc extracts the scope a CircuitBreaker tracks state for, e.g. the
+// route pattern from httprouter.Params, or the request path by default.
+type KeyFunc func(*http.Request) string
+
+// BreakerMetrics is a point-in-time snapshot of one route's breaker,
+// meant to be read by withMetrics and surfaced as Prometheus gauges/counters.
+type BreakerMetrics struct {
+	State          BreakerState
+	Requests       int
+	Failures       int
+	OpenFor        time.Duration
+	ConsecutiveTrips int
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// routeBreaker is the three-state machine (Closed -> Open -> Half-Open ->
+// Closed/Open) for a single route key.
+type routeBreaker struct {
+	mu  sync.Mutex
+	key string
+	cfg BreakerConfig
+	// onStateChange fires whenever state actually changes. routeBreakerFor
+	// wires this to a closure that reads CircuitBreaker.OnStateChange at
+	// call time rather than capturing its value, so replacing
+	// CircuitBreaker.OnStateChange later still reaches every already-created
+	// routeBreaker.
+	onStateChange func(key string, from, to BreakerState)
+
+	state     BreakerState
+	openUntil time.Time
+	// currentOpenFor backs off exponentially across repeated trips and
+	// resets to cfg.OpenFor once the breaker closes again.
+	currentOpenFor   time.Duration
+	consecutiveTrips int
+
+	window []outcome
+
+	halfOpenInFlight int
+}
+
+func newRouteBreaker(key string, cfg BreakerConfig, onStateChange func(string, BreakerState, BreakerState)) *routeBreaker {
+	return &routeBreaker{key: key, cfg: cfg, currentOpenFor: cfg.OpenFor, onStateChange: onStateChange}
+}
+
+// transition moves the breaker to to, firing onStateChange iff that's
+// actually a change. Callers must hold b.mu.
+func (b *routeBreaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	if from == to || b.onStateChange == nil {
+		return
+	}
+	b.onStateChange(b.key, from, to)
+}
+
+// admit decides whether a request may proceed, returning whether it was
+// admitted and, if rejected, how long the caller should wait.
+func (b *routeBreaker) admit() (admitted bool, retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	switch b.state {
+	case StateOpen:
+		if now.Before(b.openUntil) {
+			return false, b.openUntil.Sub(now)
+		}
+		b.transition(StateHalfOpen)
+		b.halfOpenInFlight = 0
+		fallthrough
+	case StateHalfOpen:
+		maxProbes := b.cfg.HalfOpenMaxProbes
+		if maxProbes <= 0 {
+			maxProbes = 1
+		}
+		if b.halfOpenInFlight >= maxProbes {
+			return false, b.currentOpenFor
+		}
+		b.halfOpenInFlight++
+		return true, 0
+	default: // StateClosed
+		return true, 0
+	}
+}
+
+// report records the outcome of an admitted request and drives state
+// transitions.
+func (b *routeBreaker) report(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	if b.state == StateHalfOpen {
+		b.halfOpenInFlight--
+		if failed {
+			b.trip(now)
+		} else {
+			b.close()
+		}
+		return
+	}
+
+	b.window = append(b.window, outcome{at: now, failed: failed})
+	b.window = pruneWindow(b.window, now, b.cfg.Window)
+
+	if len(b.window) < b.cfg.MinRequests {
+		return
+	}
+
+	failures := 0
+	for _, o := range b.window {
+		if o.failed {
+			failures++
+		}
+	}
+
+	if float64(failures)/float64(len(b.window)) >= b.cfg.FailureRatio {
+		b.trip(now)
+	}
+}
+
+// trip opens the breaker, exponentially backing off OpenFor on repeated
+// trips.
+func (b *routeBreaker) trip(now time.Time) {
+	b.consecutiveTrips++
+	backoff := b.cfg.OpenFor << (b.consecutiveTrips - 1)
+	if b.cfg.MaxOpenFor > 0 && backoff > b.cfg.MaxOpenFor {
+		backoff = b.cfg.MaxOpenFor
+	}
+	b.currentOpenFor = backoff
+	b.openUntil = now.Add(backoff)
+	b.window = nil
+	b.transition(StateOpen)
+}
+
+// close resets the breaker to Closed after a successful Half-Open probe.
+func (b *routeBreaker) close() {
+	b.consecutiveTrips = 0
+	b.currentOpenFor = b.cfg.OpenFor
+	b.window = nil
+	b.transition(StateClosed)
+}
+
+func (b *routeBreaker) snapshot() BreakerMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failures := 0
+	for _, o := range b.window {
+		if o.failed {
+			failures++
+		}
+	}
+
+	return BreakerMetrics{
+		State:            b.state,
+		Requests:         len(b.window),
+		Failures:         failures,
+		OpenFor:          b.currentOpenFor,
+		ConsecutiveTrips: b.consecutiveTrips,
+	}
+}
+
+func pruneWindow(window []outcome, now time.Time, size time.Duration) []outcome {
+	cutoff := now.Add(-size)
+	i := 0
+	for i < len(window) && window[i].at.Before(cutoff) {
+		i++
+	}
+	return window[i:]
+}
+
+// CircuitBreaker is a three-state (Closed/Open/Half-Open) circuit breaker
+// scoped per route: each key returned by the configured KeyFunc gets its
+// own independent breaker, so a failing route doesn't trip requests to a
+// healthy one.
+type CircuitBreaker struct {
+	cfg      BreakerConfig
+	keyFunc  KeyFunc
+	breakers sync.Map // string -> *routeBreaker
+
+	// OnStateChange, if set, is called whenever any route's breaker changes
+	// state. It defaults to logging the transition; assign a new func to
+	// replace that (e.g. to emit a metric instead) - routeBreakerFor reads
+	// this field at call time, so the replacement reaches breakers created
+	// both before and after the assignment.
+	OnStateChange func(key string, from, to BreakerState)
+}
+
+// NewCircuitBreaker creates a CircuitBreaker keyed by request path.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	return NewCircuitBreakerWithKeyFunc(cfg, func(r *http.Request) string { return r.URL.Path })
+}
+
+// NewCircuitBreakerWithKeyFunc creates a CircuitBreaker scoped by whatever
+// keyFunc returns, e.g. an httprouter route pattern instead of the raw path.
+func NewCircuitBreakerWithKeyFunc(cfg BreakerConfig, keyFunc KeyFunc) *CircuitBreaker {
+	if cfg.HalfOpenMaxProbes <= 0 {
+		cfg.HalfOpenMaxProbes = 1
+	}
+	return &CircuitBreaker{
+		cfg:     cfg,
+		keyFunc: keyFunc,
+		OnStateChange: func(key string, from, to BreakerState) {
+			log.Printf("circuit breaker %q: %s -> %s", key, from, to)
+		},
+	}
+}
+
+func (cb *CircuitBreaker) routeBreakerFor(key string) *routeBreaker {
+	if rb, ok := cb.breakers.Load(key); ok {
+		return rb.(*routeBreaker)
+	}
+
+	rb := newRouteBreaker(key, cb.cfg, func(key string, from, to BreakerState) {
+		if cb.OnStateChange != nil {
+			cb.OnStateChange(key, from, to)
+		}
+	})
+	actual, _ := cb.breakers.LoadOrStore(key, rb)
+	return actual.(*routeBreaker)
+}
+
+// Middleware wraps next with the circuit breaker, rejecting requests with
+// 503 and a Retry-After header while the route's breaker is Open or its
+// Half-Open probe slots are full.
+func (cb *CircuitBreaker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rb := cb.routeBreakerFor(cb.keyFunc(r))
+
+		admitted, retryAfter := rb.admit()
+		if !admitted {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+1)))
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		rr := &respRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rr, r)
+
+		rb.report(rr.code >= http.StatusInternalServerError)
+	})
+}
+
+// State returns the current breaker state for key (e.g. a route pattern).
+// Routes that have never seen a request report StateClosed.
+func (cb *CircuitBreaker) State(key string) BreakerState {
+	return cb.routeBreakerFor(key).snapshot().State
+}
+
+// Metrics returns a point-in-time snapshot for key, for withMetrics to
+// surface as Prometheus gauges/counters.
+func (cb *CircuitBreaker) Metrics(key string) BreakerMetrics {
+	return cb.routeBreakerFor(key).snapshot()
+}
+
+// Keys returns every route key the breaker currently tracks state for.
+func (cb *CircuitBreaker) Keys() []string {
+	var keys []string
+	cb.breakers.Range(func(k, _ interface{}) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	return keys
+}
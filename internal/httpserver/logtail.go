@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/logtail.go
+package httpserver
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+
+	"highperf-api/internal/logger"
+)
+
+// actorOverrideCloseCode is the close code sent to a tail session's socket
+// when a new session for the same actor takes over, so the client can tell
+// "you were replaced" apart from a normal disconnect.
+const actorOverrideCloseCode = 4000
+
+// LogTailer serves GET /debug/logs/tail: it upgrades to a WebSocket and
+// streams slog.Record frames from a logger.Logger's Tap as they're
+// produced. At most one session per actor is kept; a new login from the
+// same actor closes the older session rather than letting it dangle.
+type LogTailer struct {
+	log         *logger.Logger
+	maxSessions int
+	idleTimeout time.Duration
+	upgrader    websocket.Upgrader
+
+	mu       sync.Mutex
+	sessions map[string]chan struct{} // actor -> close signal for its current session
+}
+
+// NewLogTailer creates a LogTailer over log, allowing at most maxSessions
+// concurrent tails (0 means unlimited) and closing a session idle longer
+// than idleTimeout (0 means no idle timeout).
+func NewLogTailer(log *logger.Logger, maxSessions int, idleTimeout time.Duration) *LogTailer {
+	return &LogTailer{
+		log:         log,
+		maxSessions: maxSessions,
+		idleTimeout: idleTimeout,
+		upgrader:    websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096},
+		sessions:    make(map[string]chan struct{}),
+	}
+}
+
+// Handle builds the httprouter.Handle for GET /debug/logs/tail.
+func (t *LogTailer) Handle() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		actor := actorFromRequest(r)
+		predicate := parseLogFilter(r.URL.Query())
+
+		closeSignal, reject := t.claimSlot(actor)
+		if reject {
+			http.Error(w, "too many concurrent log tails", http.StatusServiceUnavailable)
+			return
+		}
+
+		conn, err := t.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.release(actor, closeSignal)
+			return
+		}
+		defer conn.Close()
+
+		sub, unsubscribe := t.log.Tap(256)
+		defer unsubscribe()
+		defer t.release(actor, closeSignal)
+
+		t.stream(conn, sub, closeSignal, predicate)
+	}
+}
+
+// claimSlot registers actor's close-signal channel, closing out any
+// pre-existing session for the same actor first. reject is true when
+// maxSessions is already reached by a *different* actor.
+func (t *LogTailer) claimSlot(actor string) (closeSignal chan struct{}, reject bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if old, ok := t.sessions[actor]; ok {
+		close(old)
+	} else if t.maxSessions > 0 && len(t.sessions) >= t.maxSessions {
+		return nil, true
+	}
+
+	ch := make(chan struct{})
+	t.sessions[actor] = ch
+	return ch, false
+}
+
+// release drops actor's session iff it is still the one identified by
+// closeSignal (an override may have already replaced it).
+func (t *LogTailer) release(actor string, closeSignal chan struct{}) {
+	if closeSignal == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.sessions[actor] == closeSignal {
+		delete(t.sessions, actor)
+	}
+	t.mu.Unlock()
+}
+
+func (t *LogTailer) stream(conn *websocket.Conn, sub *logger.Subscription, closeSignal chan struct{}, predicate func(slog.Record) bool) {
+	var idle <-chan time.Time
+	if t.idleTimeout > 0 {
+		timer := time.NewTimer(t.idleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	for {
+		select {
+		case <-closeSignal:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(actorOverrideCloseCode, "superseded by a newer session"),
+				time.Now().Add(time.Second))
+			return
+		case <-idle:
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout"),
+				time.Now().Add(time.Second))
+			return
+		case rec, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if predicate != nil && !predicate(rec) {
+				continue
+			}
+			if err := conn.WriteJSON(recordFrame(rec, sub.Dropped())); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// logFrame is one JSON frame streamed to a log-tail client.
+type logFrame struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Dropped int64          `json:"dropped_since_connect"`
+}
+
+func recordFrame(r slog.Record, dropped int64) logFrame {
+	attrs := make(map[string]any)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	return logFrame{
+		Time:    r.Time,
+		Level:   r.Level.String(),
+		Message: r.Message,
+		Attrs:   attrs,
+		Dropped: dropped,
+	}
+}
+
+// actorFromRequest identifies who is tailing logs, for the per-actor
+// override rule. A real deployment would pull this from the authenticated
+// principal (e.g. oauth.UserIDFromContext); fall back to an explicit query
+// parameter so the endpoint is still usable ungated.
+func actorFromRequest(r *http.Request) string {
+	if actor := r.URL.Query().Get("actor"); actor != "" {
+		return actor
+	}
+	return "anonymous:" + r.RemoteAddr
+}
+
+// parseLogFilter builds a predicate from min_level, event, and attr_*
+// query parameters; nil means "no filtering".
+func parseLogFilter(q map[string][]string) func(slog.Record) bool {
+	var minLevel slog.Level
+	hasMinLevel := false
+	if v := first(q, "min_level"); v != "" {
+		if err := minLevel.UnmarshalText([]byte(strings.ToUpper(v))); err == nil {
+			hasMinLevel = true
+		}
+	}
+	event := first(q, "event")
+
+	attrMatch := make(map[string]string)
+	for k, v := range q {
+		if strings.HasPrefix(k, "attr_") && len(v) > 0 {
+			attrMatch[strings.TrimPrefix(k, "attr_")] = v[0]
+		}
+	}
+
+	if !hasMinLevel && event == "" && len(attrMatch) == 0 {
+		return nil
+	}
+
+	return func(r slog.Record) bool {
+		if hasMinLevel && r.Level < minLevel {
+			return false
+		}
+		if event != "" && r.Message != event {
+			return false
+		}
+		for key, want := range attrMatch {
+			got := ""
+			r.Attrs(func(a slog.Attr) bool {
+				if a.Key == key {
+					got = a.Value.String()
+					return false
+				}
+				return true
+			})
+			if got != want {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func first(q map[string][]string, key string) string {
+	if v, ok := q[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
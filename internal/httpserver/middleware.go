@@ -20,12 +20,13 @@ package httpserver
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"log"
-	"math"
-	"math/rand"
 	"net/http"
-	"sync"
 	"time"
+
+	"highperf-api/internal/logger"
 )
 
 func withServerHeader(next http.Handler) http.Handler {
@@ -56,105 +57,8 @@ func withTimeouts(next http.Handler) http.Handler {
 	})
 }
 
-// --- Rate Limiter con estado encapsulado ---
-
-type RateLimiter struct {
-	mu     sync.Mutex
-	tokens int
-	last   time.Time
-	cap    int
-	refill int
-	per    time.Duration
-}
-
-func NewRateLimiter(capacity, refillRate int, per time.Duration) *RateLimiter {
-	return &RateLimiter{
-		tokens: capacity,
-		last:   time.Now(),
-		cap:    capacity,
-		refill: refillRate,
-		per:    per,
-	}
-}
-
-func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		now := time.Now()
-		rl.mu.Lock()
-
-		elapsed := now.Sub(rl.last)
-		if elapsed > 0 {
-			n := int(float64(rl.refill) * elapsed.Seconds() / rl.per.Seconds())
-			if n > 0 {
-				rl.tokens = min(rl.cap, rl.tokens+n)
-				rl.last = now
-			}
-		}
-
-		if rl.tokens <= 0 {
-			rl.mu.Unlock()
-			http.Error(w, "too many requests", http.StatusTooManyRequests)
-			return
-		}
-		rl.tokens--
-		rl.mu.Unlock()
-		next.ServeHTTP(w, r)
-	})
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// --- Circuit Breaker con estado encapsulado ---
-
-type CircuitBreaker struct {
-	mu               sync.Mutex
-	failures         int
-	openUntil        time.Time
-	failureThreshold int
-	openFor          time.Duration
-}
-
-func NewCircuitBreaker(failureThreshold int, openFor time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
-		failureThreshold: failureThreshold,
-		openFor:          openFor,
-	}
-}
-
-func (cb *CircuitBreaker) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cb.mu.Lock()
-		if time.Now().Before(cb.openUntil) {
-			cb.mu.Unlock()
-			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
-			return
-		}
-		cb.mu.Unlock()
-
-		jitter := time.Duration(rand.Intn(2)) * time.Millisecond
-		time.Sleep(jitter)
-
-		rr := &respRecorder{ResponseWriter: w, code: http.StatusOK}
-		next.ServeHTTP(rr, r)
-
-		cb.mu.Lock()
-		if rr.code >= http.StatusInternalServerError {
-			cb.failures++
-			if cb.failures >= cb.failureThreshold {
-				cb.openUntil = time.Now().Add(cb.openFor)
-				cb.failures = 0
-			}
-		} else {
-			cb.failures = int(math.Max(0, float64(cb.failures-2)))
-		}
-		cb.mu.Unlock()
-	})
-}
+// The sharded, per-identity token-bucket RateLimiter lives in ratelimit.go.
+// The three-state circuit breaker (Closed/Open/Half-Open) lives in breaker.go.
 
 type respRecorder struct {
 	http.ResponseWriter
@@ -175,9 +79,28 @@ func withMetrics(next http.Handler) http.Handler {
 	})
 }
 
+// withTracing stamps every request with a trace/span id pair and attaches
+// it to the request context via logger.WithTraceContext, so any
+// logger.Logger call made downstream (HTTPRequest, Performance,
+// BusinessEvent, ...) automatically correlates with this request once it
+// reaches a trace-aware Sink (the OTLP exporter promotes these to the
+// LogRecord's trace_id/span_id fields).
 func withTracing(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("Tracing: starting trace for %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
+		tc := logger.TraceContext{TraceID: randomTraceID(16), SpanID: randomTraceID(8)}
+		ctx := logger.WithTraceContext(r.Context(), tc)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// randomTraceID returns a random n-byte id hex-encoded, matching the
+// trace_id (16 bytes) / span_id (8 bytes) widths OTLP expects. Falls back
+// to an all-zero id on an (essentially impossible) crypto/rand failure
+// rather than panicking mid-request.
+func randomTraceID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("randomTraceID: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -146,7 +146,14 @@ func TestWithBreaker(t *testing.T) {
 		}
 	})
 
-	middleware := withBreaker(handler)
+	cb := NewCircuitBreaker(BreakerConfig{
+		Window:            time.Second,
+		MinRequests:       20,
+		FailureRatio:      0.5,
+		OpenFor:           2 * time.Second,
+		HalfOpenMaxProbes: 1,
+	})
+	middleware := cb.Middleware(handler)
 
 	// Send enough failing requests to trip the circuit breaker
 	for i := 0; i < 20; i++ {
@@ -186,6 +193,50 @@ func TestWithBreaker(t *testing.T) {
 	}
 }
 
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	failing := true
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	cb := NewCircuitBreaker(BreakerConfig{
+		Window:            time.Second,
+		MinRequests:       2,
+		FailureRatio:      0.5,
+		OpenFor:           50 * time.Millisecond,
+		HalfOpenMaxProbes: 1,
+	})
+	middleware := cb.Middleware(handler)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/flaky", nil)
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+	}
+
+	if got := cb.State("/flaky"); got != StateOpen {
+		t.Fatalf("expected breaker to be Open after tripping, got %v", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	failing = false
+
+	req := httptest.NewRequest("GET", "/flaky", nil)
+	w := httptest.NewRecorder()
+	middleware.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected half-open probe to succeed with 200, got %d", w.Code)
+	}
+	if got := cb.State("/flaky"); got != StateClosed {
+		t.Errorf("expected breaker to close after a successful probe, got %v", got)
+	}
+}
+
 func TestRespRecorder(t *testing.T) {
 	w := httptest.NewRecorder()
 	recorder := &respRecorder{ResponseWriter: w, code: 200}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/oauth.go
+package httpserver
+
+import (
+	"context"
+	"net/http"
+
+	"highperf-api/internal/auth"
+	"highperf-api/internal/oauth"
+)
+
+type oauthContextKey int
+
+const principalContextKey oauthContextKey = iota
+
+// PrincipalFromContext retrieves the oauth.Principal withOAuth resolved
+// the Bearer token to, for handlers mounted behind it.
+func PrincipalFromContext(ctx context.Context) (*oauth.Principal, bool) {
+	p, ok := ctx.Value(principalContextKey).(*oauth.Principal)
+	return p, ok
+}
+
+// withOAuth requires a valid `Authorization: Bearer` access token carrying
+// every scope in required, and injects the resolved oauth.Principal into
+// the request context for downstream handlers. Unlike the password-login
+// JWT middleware this validates against srv's TokenStore too, so a
+// revoked token is rejected even if its signature and exp still check out.
+func withOAuth(srv *oauth.Server, required ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := auth.ExtractTokenFromBearer(r.Header.Get("Authorization"))
+			if err != nil {
+				http.Error(w, "missing or malformed bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			principal, err := srv.Authenticate(r.Context(), token, required...)
+			if err != nil {
+				http.Error(w, "invalid or insufficient access token", http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), principalContextKey, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
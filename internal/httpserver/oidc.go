@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/oidc.go
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"highperf-api/internal/auth"
+)
+
+const (
+	oidcStateCookie    = "oidc_state"
+	oidcNonceCookie    = "oidc_nonce"
+	oidcVerifierCookie = "oidc_verifier"
+)
+
+// OIDCProviders maps the `:provider` path parameter (e.g. "google",
+// "github-oidc") to the OIDCProvider that handles it, so a single pair of
+// routes serves every configured provider.
+type OIDCProviders map[string]*auth.OIDCProvider
+
+// OIDCIdentityHandler is invoked once a callback's ID token has been
+// verified, so the caller can upsert a local user and issue a session -
+// the OIDC counterpart of connectors.IdentityHandler.
+type OIDCIdentityHandler func(w http.ResponseWriter, r *http.Request, provider string, claims *auth.IDClaims)
+
+// newOIDCLoginHandler builds GET /auth/oidc/:provider/login: it mints
+// state, nonce, and a PKCE code_verifier, stashes them in short-lived
+// cookies, and redirects to the provider's authorization endpoint.
+func newOIDCLoginHandler(providers OIDCProviders) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		p, ok := providers[ps.ByName("provider")]
+		if !ok {
+			http.Error(w, "unknown provider", http.StatusNotFound)
+			return
+		}
+
+		state, err := randomOIDCValue()
+		if err != nil {
+			http.Error(w, "failed to issue state", http.StatusInternalServerError)
+			return
+		}
+		nonce, err := randomOIDCValue()
+		if err != nil {
+			http.Error(w, "failed to issue nonce", http.StatusInternalServerError)
+			return
+		}
+		verifier, challenge, err := auth.GeneratePKCE()
+		if err != nil {
+			http.Error(w, "failed to issue pkce challenge", http.StatusInternalServerError)
+			return
+		}
+
+		setOIDCCookie(w, r, oidcStateCookie, state)
+		setOIDCCookie(w, r, oidcNonceCookie, nonce)
+		setOIDCCookie(w, r, oidcVerifierCookie, verifier)
+
+		http.Redirect(w, r, p.AuthCodeURL(state, nonce, challenge), http.StatusFound)
+	}
+}
+
+// newOIDCCallbackHandler builds GET /auth/oidc/:provider/callback: it
+// checks state and nonce against the cookies the login step set, exchanges
+// the code (with PKCE) for an ID token, verifies it, and hands the
+// resulting claims to onIdentity.
+func newOIDCCallbackHandler(providers OIDCProviders, onIdentity OIDCIdentityHandler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		providerName := ps.ByName("provider")
+		p, ok := providers[providerName]
+		if !ok {
+			http.Error(w, "unknown provider", http.StatusNotFound)
+			return
+		}
+
+		state, nonce, verifier, ok := consumeOIDCCookies(w, r)
+		if !ok {
+			http.Error(w, "missing or expired login attempt", http.StatusBadRequest)
+			return
+		}
+
+		q := r.URL.Query()
+		if q.Get("state") == "" || q.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		tokens, err := p.Exchange(r.Context(), q.Get("code"), verifier)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("code exchange failed: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		claims, err := p.VerifyIDToken(r.Context(), tokens.IDToken)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("id token verification failed: %v", err), http.StatusUnauthorized)
+			return
+		}
+		if claims.Nonce != nonce {
+			http.Error(w, "nonce mismatch", http.StatusUnauthorized)
+			return
+		}
+
+		onIdentity(w, r, providerName, claims)
+	}
+}
+
+func randomOIDCValue() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func setOIDCCookie(w http.ResponseWriter, r *http.Request, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth/oidc",
+		MaxAge:   300,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// consumeOIDCCookies reads back the state/nonce/verifier cookies set at
+// login and clears them, so a callback can't be replayed against the same
+// login attempt twice.
+func consumeOIDCCookies(w http.ResponseWriter, r *http.Request) (state, nonce, verifier string, ok bool) {
+	values := make(map[string]string, 3)
+	for _, name := range []string{oidcStateCookie, oidcNonceCookie, oidcVerifierCookie} {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return "", "", "", false
+		}
+		values[name] = c.Value
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/auth/oidc",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return values[oidcStateCookie], values[oidcNonceCookie], values[oidcVerifierCookie], true
+}
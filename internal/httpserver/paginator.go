@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/paginator.go
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Paginator turns a (total, page, pageSize) result into the headers list
+// endpoints should return, so audit logs, sessions, and anything else paged
+// the same way don't each reimplement RFC 5988 Link construction.
+type Paginator struct {
+	Total    int
+	Page     int
+	PageSize int
+}
+
+// TotalPages is the number of pages Total splits into at PageSize per page.
+// Zero when PageSize is zero or there are no rows.
+func (p Paginator) TotalPages() int {
+	if p.PageSize <= 0 {
+		return 0
+	}
+	return (p.Total + p.PageSize - 1) / p.PageSize
+}
+
+// WriteHeaders sets X-Total-Count and, when there's more than one page, an
+// RFC 5988 Link header with first/prev/next/last rel values built from
+// reqURL - every existing query parameter is preserved, only "page" is
+// rewritten per link.
+func (p Paginator) WriteHeaders(w http.ResponseWriter, reqURL *url.URL) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(p.Total))
+
+	if link := p.linkHeader(reqURL); link != "" {
+		w.Header().Set("Link", link)
+	}
+}
+
+func (p Paginator) linkHeader(reqURL *url.URL) string {
+	last := p.TotalPages()
+	if last <= 1 {
+		return ""
+	}
+
+	type relPage struct {
+		rel  string
+		page int
+	}
+	rels := []relPage{{"first", 1}, {"last", last}}
+	if p.Page > 1 {
+		rels = append(rels, relPage{"prev", p.Page - 1})
+	}
+	if p.Page < last {
+		rels = append(rels, relPage{"next", p.Page + 1})
+	}
+
+	links := make([]string, 0, len(rels))
+	for _, rp := range rels {
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, p.pageURL(reqURL, rp.page), rp.rel))
+	}
+	return strings.Join(links, ", ")
+}
+
+// pageURL clones reqURL with its "page" query parameter set to page,
+// leaving every other parameter (filters, sort, page_size) untouched.
+func (p Paginator) pageURL(reqURL *url.URL, page int) string {
+	u := *reqURL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
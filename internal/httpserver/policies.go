@@ -0,0 +1,262 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/policies.go
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"highperf-api/internal/encoding/jsonx"
+	"highperf-api/internal/scheduler"
+)
+
+// newListPoliciesHandler builds GET /policies: job_kind/enabled filters
+// plus page/page_size, the same query-parameter and Paginator convention
+// as GET /users and GET /audit.
+func newListPoliciesHandler(policies scheduler.PolicyStore) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		q := r.URL.Query()
+
+		var opts scheduler.ListOptions
+		opts.Page = 1
+		if v := q.Get("page"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "invalid query parameter: page", http.StatusBadRequest)
+				return
+			}
+			opts.Page = n
+		}
+		opts.PageSize = 20
+		if v := q.Get("page_size"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 100 {
+				http.Error(w, "invalid query parameter: page_size", http.StatusBadRequest)
+				return
+			}
+			opts.PageSize = n
+		}
+		opts.Filters.JobKind = q.Get("job_kind")
+		if v := q.Get("enabled"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "invalid query parameter: enabled", http.StatusBadRequest)
+				return
+			}
+			opts.Filters.Enabled = &b
+		}
+
+		list, total, err := policies.List(r.Context(), opts)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		Paginator{Total: total, Page: opts.Page, PageSize: opts.PageSize}.WriteHeaders(w, r.URL)
+		_ = jsonx.WriteJSON(w, http.StatusOK, list)
+	}
+}
+
+// policyRequest is the POST /policies and PUT /policies/:id request body.
+type policyRequest struct {
+	Name        string                `json:"name"`
+	CronExpr    string                `json:"cron_str"`
+	Trigger     scheduler.TriggerKind `json:"trigger_kind"`
+	Description string                `json:"description"`
+	JobKind     string                `json:"job_kind"`
+	Params      json.RawMessage       `json:"params,omitempty"`
+}
+
+// newCreatePolicyHandler builds POST /policies. New policies are created
+// enabled; Reschedule picks them up in sched's fire heap immediately
+// instead of waiting for the next restart.
+func newCreatePolicyHandler(policies scheduler.PolicyStore, sched *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		var req policyRequest
+		if err := jsonx.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		if req.Trigger == "" {
+			req.Trigger = scheduler.TriggerScheduled
+		}
+
+		created, err := policies.Create(r.Context(), &scheduler.Policy{
+			Name:        req.Name,
+			Enabled:     true,
+			CronExpr:    req.CronExpr,
+			Trigger:     req.Trigger,
+			Description: req.Description,
+			JobKind:     req.JobKind,
+			Params:      req.Params,
+		})
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if sched != nil {
+			sched.Reschedule(created)
+		}
+		_ = jsonx.WriteJSON(w, http.StatusCreated, created)
+	}
+}
+
+// newUpdatePolicyHandler builds PUT /policies/:id.
+func newUpdatePolicyHandler(policies scheduler.PolicyStore, sched *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		id, err := parsePolicyID(ps)
+		if err != nil {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+
+		existing, err := policies.GetByID(r.Context(), id)
+		if err != nil {
+			writePolicyStoreError(w, err)
+			return
+		}
+
+		var req policyRequest
+		if err := jsonx.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+
+		existing.Name = req.Name
+		existing.CronExpr = req.CronExpr
+		existing.Trigger = req.Trigger
+		existing.Description = req.Description
+		existing.JobKind = req.JobKind
+		existing.Params = req.Params
+
+		updated, err := policies.Update(r.Context(), existing)
+		if err != nil {
+			writePolicyStoreError(w, err)
+			return
+		}
+
+		if sched != nil {
+			sched.Reschedule(updated)
+		}
+		_ = jsonx.WriteJSON(w, http.StatusOK, updated)
+	}
+}
+
+// newSetPolicyEnabledHandler builds the handler behind both
+// POST /policies/:id/enable and POST /policies/:id/disable.
+func newSetPolicyEnabledHandler(policies scheduler.PolicyStore, sched *scheduler.Scheduler, enabled bool) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		id, err := parsePolicyID(ps)
+		if err != nil {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+
+		if err := policies.SetEnabled(r.Context(), id, enabled); err != nil {
+			writePolicyStoreError(w, err)
+			return
+		}
+
+		if sched != nil {
+			if p, err := policies.GetByID(r.Context(), id); err == nil {
+				sched.Reschedule(p)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newTriggerPolicyHandler builds POST /policies/:id/trigger: it runs the
+// policy's job immediately, outside its cron schedule, and waits for the
+// result before responding.
+func newTriggerPolicyHandler(sched *scheduler.Scheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		id, err := parsePolicyID(ps)
+		if err != nil {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+
+		if err := sched.TriggerNow(r.Context(), id); err != nil {
+			if errors.Is(err, scheduler.ErrPolicyNotFound) {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// newListExecutionsHandler builds GET /policies/:id/executions.
+func newListExecutionsHandler(executions scheduler.ExecutionStore) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		id, err := parsePolicyID(ps)
+		if err != nil {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+
+		page := 1
+		if v := r.URL.Query().Get("page"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "invalid query parameter: page", http.StatusBadRequest)
+				return
+			}
+			page = n
+		}
+		pageSize := 20
+		if v := r.URL.Query().Get("page_size"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 100 {
+				http.Error(w, "invalid query parameter: page_size", http.StatusBadRequest)
+				return
+			}
+			pageSize = n
+		}
+
+		list, total, err := executions.ListByPolicy(r.Context(), id, page, pageSize)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		Paginator{Total: total, Page: page, PageSize: pageSize}.WriteHeaders(w, r.URL)
+		_ = jsonx.WriteJSON(w, http.StatusOK, list)
+	}
+}
+
+func parsePolicyID(ps httprouter.Params) (int64, error) {
+	return strconv.ParseInt(ps.ByName("id"), 10, 64)
+}
+
+func writePolicyStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, scheduler.ErrPolicyNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,374 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/ratelimit.go
+package httpserver
+
+import (
+	"container/heap"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"highperf-api/internal/encoding/jsonx"
+	apierrors "highperf-api/internal/errors"
+)
+
+// RateLimitPolicy configures one identity's token bucket: it starts full at
+// Capacity+Burst tokens and refills up to that same ceiling, gaining Refill
+// tokens every Per; a request is rejected once the bucket is empty.
+type RateLimitPolicy struct {
+	Capacity int
+	Refill   int
+	Per      time.Duration
+	Burst    int
+}
+
+func (p RateLimitPolicy) max() int64 {
+	return int64(p.Capacity + p.Burst)
+}
+
+// ExternalDecider is consulted before the local token bucket check, so an
+// operator can front the API with a CrowdSec-style bouncer: Blocked
+// returning (true, nil) rejects the request without touching local state.
+// A nil/unreachable decider (non-nil error) is treated as "not blocked" -
+// the local bucket is the source of truth either way.
+type ExternalDecider interface {
+	Blocked(ctx context.Context, identity string) (bool, error)
+}
+
+// bucket is one (route, identity) pair's token bucket. Tokens are refilled
+// lazily on access - there is no background goroutine ticking every
+// bucket - so an idle bucket costs nothing until it's touched again.
+type bucket struct {
+	tokens         int64 // atomic
+	lastRefillNano int64 // atomic, unix nano
+	lastAccessNano int64 // atomic, unix nano; read by the idle evictor
+}
+
+// take attempts to consume one token, refilling first based on elapsed
+// time since the last refill. It reports whether the request is allowed,
+// how many tokens remain, and - when rejected - how long until the next
+// token is available.
+func (b *bucket) take(policy RateLimitPolicy, now time.Time) (allowed bool, remaining int64, retryAfter time.Duration) {
+	nowNano := now.UnixNano()
+	atomic.StoreInt64(&b.lastAccessNano, nowNano)
+	capTokens := policy.max()
+
+	for {
+		last := atomic.LoadInt64(&b.lastRefillNano)
+		cur := atomic.LoadInt64(&b.tokens)
+
+		refilled := cur
+		newLast := last
+		if elapsed := nowNano - last; elapsed > 0 && policy.Per > 0 && policy.Refill > 0 {
+			add := int64(float64(policy.Refill) * float64(elapsed) / float64(policy.Per))
+			if add > 0 {
+				refilled = cur + add
+				if refilled > capTokens {
+					refilled = capTokens
+				}
+				newLast = nowNano
+			}
+		}
+
+		allowed = refilled >= 1
+		next := refilled
+		if allowed {
+			next--
+		}
+
+		if !atomic.CompareAndSwapInt64(&b.tokens, cur, next) {
+			continue // lost the race with a concurrent take; retry with fresh values
+		}
+		atomic.CompareAndSwapInt64(&b.lastRefillNano, last, newLast)
+
+		if allowed {
+			return true, next, 0
+		}
+		missing := int64(1) - refilled
+		if missing < 1 {
+			missing = 1
+		}
+		if policy.Refill <= 0 {
+			return false, 0, policy.Per
+		}
+		waitNano := int64(float64(missing) * float64(policy.Per) / float64(policy.Refill))
+		return false, 0, time.Duration(waitNano)
+	}
+}
+
+// evictEntry is one bucket tracked in RateLimiter's idle-eviction heap.
+// lastAccess is a snapshot taken when the entry was queued or last
+// resorted - bucket.lastAccessNano is re-read from the live bucket at
+// sweep time, since it keeps changing underneath the heap between ticks.
+type evictEntry struct {
+	key        string
+	bucket     *bucket
+	lastAccess int64
+	index      int
+}
+
+type bucketHeap []*evictEntry
+
+func (h bucketHeap) Len() int            { return len(h) }
+func (h bucketHeap) Less(i, j int) bool  { return h[i].lastAccess < h[j].lastAccess }
+func (h bucketHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *bucketHeap) Push(x interface{}) {
+	e := x.(*evictEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *bucketHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
+
+// RateLimitMetrics is a point-in-time snapshot, meant to be read by
+// withMetrics and surfaced as Prometheus counters labeled by route and
+// decision (allowed/rejected).
+type RateLimitMetrics struct {
+	Allowed        uint64
+	Rejected       uint64
+	TrackedBuckets int
+}
+
+// RateLimiter is a sharded token-bucket limiter: each (route, identity)
+// pair - identity from KeyFunc, route from RouteFunc - gets its own
+// independent bucket, so one noisy client or route doesn't starve anyone
+// else. Per-route quotas can override the default Policy via
+// SetRoutePolicy, and an ExternalDecider can veto a request before the
+// local bucket is even consulted.
+type RateLimiter struct {
+	KeyFunc   KeyFunc
+	RouteFunc KeyFunc
+	External  ExternalDecider
+
+	policy        RateLimitPolicy
+	routePolicies sync.Map // map[string]RateLimitPolicy
+	buckets       sync.Map // map[string]*bucket
+
+	mu        sync.Mutex
+	evictHeap bucketHeap
+	idleTTL   time.Duration
+	stop      chan struct{}
+
+	allowed, rejected uint64 // atomic
+}
+
+// NewRateLimiter creates a RateLimiter with a single default policy and
+// identity extracted from X-Forwarded-For/X-Real-IP/RemoteAddr.
+func NewRateLimiter(capacity, refillRate int, per time.Duration) *RateLimiter {
+	return NewRateLimiterWithKeyFunc(RateLimitPolicy{Capacity: capacity, Refill: refillRate, Per: per}, ipKeyFunc)
+}
+
+// NewRateLimiterWithKeyFunc creates a RateLimiter scoped by whatever
+// keyFunc returns - an authenticated user id, an API key, a parsed client
+// IP - instead of the default IP-based extraction.
+func NewRateLimiterWithKeyFunc(policy RateLimitPolicy, keyFunc KeyFunc) *RateLimiter {
+	rl := &RateLimiter{
+		KeyFunc: keyFunc,
+		policy:  policy,
+		idleTTL: 10 * time.Minute,
+		stop:    make(chan struct{}),
+	}
+	go rl.evictLoop()
+	return rl
+}
+
+// ipKeyFunc extracts the client IP from X-Forwarded-For (left-most hop),
+// falling back to X-Real-IP and finally RemoteAddr.
+func ipKeyFunc(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if i := strings.IndexByte(xff, ','); i >= 0 {
+			return strings.TrimSpace(xff[:i])
+		}
+		return strings.TrimSpace(xff)
+	}
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return xri
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// SetRoutePolicy overrides the default Policy for route, as returned by
+// RouteFunc (or r.URL.Path if RouteFunc is nil).
+func (rl *RateLimiter) SetRoutePolicy(route string, policy RateLimitPolicy) {
+	rl.routePolicies.Store(route, policy)
+}
+
+func (rl *RateLimiter) policyFor(route string) RateLimitPolicy {
+	if v, ok := rl.routePolicies.Load(route); ok {
+		return v.(RateLimitPolicy)
+	}
+	return rl.policy
+}
+
+func (rl *RateLimiter) bucketFor(route, identity string) *bucket {
+	key := route + "\x00" + identity
+	if v, ok := rl.buckets.Load(key); ok {
+		return v.(*bucket)
+	}
+
+	b := &bucket{tokens: rl.policyFor(route).max(), lastRefillNano: time.Now().UnixNano()}
+	actual, loaded := rl.buckets.LoadOrStore(key, b)
+	b = actual.(*bucket)
+	if !loaded {
+		rl.mu.Lock()
+		heap.Push(&rl.evictHeap, &evictEntry{key: key, bucket: b, lastAccess: time.Now().UnixNano()})
+		rl.mu.Unlock()
+	}
+	return b
+}
+
+// Middleware wraps next with the rate limiter: it checks ExternalDecider
+// first, then the (route, identity) bucket, setting X-RateLimit-Limit,
+// X-RateLimit-Remaining, and X-RateLimit-Reset on every response that
+// reaches the bucket and Retry-After plus a structured
+// errors.ErrTooManyRequests body on rejection. A request the
+// ExternalDecider blocks gets a 403 body and none of these headers.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := rl.KeyFunc(r)
+		route := r.URL.Path
+		if rl.RouteFunc != nil {
+			route = rl.RouteFunc(r)
+		}
+		policy := rl.policyFor(route)
+
+		if rl.External != nil {
+			if blocked, err := rl.External.Blocked(r.Context(), identity); err == nil && blocked {
+				atomic.AddUint64(&rl.rejected, 1)
+				rl.writeBlocked(w)
+				return
+			}
+		}
+
+		b := rl.bucketFor(route, identity)
+		allowed, remaining, retryAfter := b.take(policy, time.Now())
+
+		w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(policy.max(), 10))
+		if !allowed {
+			atomic.AddUint64(&rl.rejected, 1)
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(retryAfter).Unix(), 10))
+			rl.writeTooManyRequests(w, retryAfter)
+			return
+		}
+
+		atomic.AddUint64(&rl.allowed, 1)
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(policy.Per).Unix(), 10))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rl *RateLimiter) writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+1)))
+	_ = jsonx.WriteJSON(w, apierrors.ErrTooManyRequests.StatusCode, apierrors.NewErrorResponse(apierrors.ErrTooManyRequests))
+}
+
+func (rl *RateLimiter) writeBlocked(w http.ResponseWriter) {
+	blocked := apierrors.ErrForbidden.WithMessage("blocked by external decision")
+	_ = jsonx.WriteJSON(w, blocked.StatusCode, apierrors.NewErrorResponse(blocked))
+}
+
+// Metrics returns a point-in-time snapshot of allowed/rejected counts and
+// the number of buckets currently tracked, for withMetrics to surface.
+func (rl *RateLimiter) Metrics() RateLimitMetrics {
+	tracked := 0
+	rl.buckets.Range(func(_, _ interface{}) bool {
+		tracked++
+		return true
+	})
+	return RateLimitMetrics{
+		Allowed:        atomic.LoadUint64(&rl.allowed),
+		Rejected:       atomic.LoadUint64(&rl.rejected),
+		TrackedBuckets: tracked,
+	}
+}
+
+// Stop ends the background idle-bucket evictor. Safe to skip for a
+// RateLimiter that lives for the process lifetime, as server.go's does.
+func (rl *RateLimiter) Stop() {
+	close(rl.stop)
+}
+
+func (rl *RateLimiter) evictLoop() {
+	ticker := time.NewTicker(rl.idleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C:
+			rl.evictIdle(time.Now())
+		}
+	}
+}
+
+// evictIdle pops the least-recently-used buckets off evictHeap, dropping
+// any whose bucket hasn't been touched within idleTTL. An entry whose live
+// lastAccessNano has moved since it was queued is re-sorted in place
+// instead of evicted - it's been used again since entering the heap.
+func (rl *RateLimiter) evictIdle(now time.Time) {
+	cutoff := now.Add(-rl.idleTTL).UnixNano()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for rl.evictHeap.Len() > 0 {
+		top := rl.evictHeap[0]
+		actual := atomic.LoadInt64(&top.bucket.lastAccessNano)
+		if actual != top.lastAccess {
+			top.lastAccess = actual
+			heap.Fix(&rl.evictHeap, 0)
+			continue
+		}
+		if actual > cutoff {
+			break
+		}
+		heap.Pop(&rl.evictHeap)
+		rl.buckets.Delete(top.key)
+	}
+}
+
+// withRateLimit is a package-default RateLimiter middleware, generous
+// enough not to interfere with normal traffic, for code that wants rate
+// limiting without constructing and wiring its own RateLimiter.
+var defaultRateLimiter = NewRateLimiter(1000, 1000, time.Second)
+
+func withRateLimit(next http.Handler) http.Handler {
+	return defaultRateLimiter.Middleware(next)
+}
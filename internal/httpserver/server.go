@@ -19,12 +19,23 @@
 package httpserver
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"highperf-api/internal/audit"
+	"highperf-api/internal/auth"
+	"highperf-api/internal/auth/connectors"
+	jwtx "highperf-api/internal/auth/jwt"
+	"highperf-api/internal/config"
 	"highperf-api/internal/handlers"
+	"highperf-api/internal/logger"
+	"highperf-api/internal/oauth"
 	"highperf-api/internal/repository"
+	"highperf-api/internal/scheduler"
 )
 
 func NewRouter() http.Handler {
@@ -34,13 +45,117 @@ func NewRouter() http.Handler {
 	// Inicialización de Dependencias (Simulado)
 	var userRepo repository.UserRepository
 	userRepo = repository.NewUserRepository(nil, nil) // CUIDADO: Esto es temporal
+
+	// =========================================================================
+	// Auditoría (Simulado)
+	auditEnabled := true // CUIDADO: en producción esto vendría de cfg.Audit.Enabled
+	var auditor audit.Auditor
+	if auditEnabled {
+		auditor = audit.NewPostgresAuditor(nil, nil) // CUIDADO: Esto es temporal
+	} else {
+		auditor = audit.NopAuditor{}
+	}
+	userRepo = audit.NewAuditedUserRepository(userRepo, auditor)
 	userHandler := handlers.NewUserHandler(userRepo)
 	// =========================================================================
 
+	// =========================================================================
+	// Servidor de autorización OAuth2 (Simulado)
+	oauthKeys, _ := auth.NewFileKeySet("./keys") // CUIDADO: Esto es temporal
+	oauthServer := oauth.NewServer(
+		oauth.NewPostgresClientStore(nil, nil),
+		oauth.NewPostgresAuthCodeStore(nil, nil),
+		oauth.NewPostgresTokenStore(nil, nil),
+		userRepo,
+		oauthKeys,
+	)
+	oauthServer.Mount(r)
+	// =========================================================================
+
+	// =========================================================================
+	// Inicio de sesión OIDC (Simulado)
+	// oidcProviders se poblaría con un auth.NewOIDCProvider por proveedor
+	// configurado (google, okta, ...) una vez cfg esté disponible aquí; con
+	// el mapa vacío, /auth/oidc/:provider/* responde 404 a cualquier
+	// proveedor, lo cual es seguro por defecto.
+	oidcProviders := OIDCProviders{}
+	jwtService := auth.NewJWTService(config.AuthConfig{}) // CUIDADO: Esto es temporal
+	oidcIdentityHandler := handlers.NewOIDCIdentityHandler(userRepo, jwtService)
+	r.GET("/auth/oidc/:provider/login", newOIDCLoginHandler(oidcProviders))
+	r.GET("/auth/oidc/:provider/callback", newOIDCCallbackHandler(oidcProviders, oidcIdentityHandler))
+	// =========================================================================
+
+	// =========================================================================
+	// Conectores de identidad externa - GitHub, OIDC genérico (Simulado)
+	// Sólo se registra GitHubConnector aquí: su constructor no hace I/O, así
+	// que es seguro instanciarlo con un GitHubConfig vacío igual que el resto
+	// de dependencias "Simulado" de esta función. OIDCConnector, en cambio,
+	// hace descubrimiento OIDC por red en su constructor, así que no se
+	// registra hasta que haya un cfg real que darle.
+	connectorRegistry := connectors.NewRegistry(handlers.NewOAuthIdentityHandler(userRepo, jwtService))
+	connectorRegistry.Register(connectors.NewGitHubConnector(connectors.GitHubConfig{})) // CUIDADO: Esto es temporal
+	connectorRegistry.Mount(r)
+	// =========================================================================
+
+	// =========================================================================
+	// Tokens de sesión firmados con go-jose (Simulado)
+	// Pista alternativa de auth.JWTService: misma idea (claims firmadas,
+	// rotación por kid) pero sobre go-jose/v3, para servicios que ya
+	// estandarizaron en esa librería.
+	sessionKeys, _ := jwtx.NewKeyManager(24 * time.Hour) // CUIDADO: Esto es temporal
+	r.GET("/auth/session/jwks.json", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		sessionKeys.ServeJWKS(w, r)
+	})
+	// =========================================================================
+
+	// =========================================================================
+	// Streaming de logs en vivo (Simulado)
+	appLogger := logger.New(logger.Config{}) // CUIDADO: Esto es temporal; en producción es el logger compartido de cmd/api
+	logTailer := NewLogTailer(appLogger, 50, 5*time.Minute)
+	r.GET("/debug/logs/tail", logTailer.Handle())
+	// =========================================================================
+
+	// =========================================================================
+	// Programador de trabajos (Simulado)
+	policyStore := scheduler.NewPostgresPolicyStore(nil, nil)       // CUIDADO: Esto es temporal
+	executionStore := scheduler.NewPostgresExecutionStore(nil, nil) // CUIDADO: Esto es temporal
+
+	jobRegistry := scheduler.NewRegistry()
+	jobRegistry.Register("audit.verify", scheduler.JobRunnerFunc(func(ctx context.Context, _ json.RawMessage) (string, error) {
+		brokenAt, err := audit.VerifyChain(ctx, nil) // CUIDADO: Esto es temporal
+		if err != nil {
+			return "", err
+		}
+		if brokenAt != 0 {
+			return "", fmt.Errorf("audit chain broken at execution id %d", brokenAt)
+		}
+		return "audit chain verified clean", nil
+	}), 1)
+	jobRegistry.Register("user.export", scheduler.JobRunnerFunc(func(context.Context, json.RawMessage) (string, error) {
+		return "", fmt.Errorf("user.export: not implemented yet")
+	}), 2)
+	jobRegistry.Register("db.vacuum", scheduler.JobRunnerFunc(func(context.Context, json.RawMessage) (string, error) {
+		return "", fmt.Errorf("db.vacuum: not implemented yet")
+	}), 1)
+
+	jobLocker := scheduler.NewPostgresLocker(nil, nil) // CUIDADO: Esto es temporal
+	jobScheduler := scheduler.NewScheduler(policyStore, executionStore, jobRegistry, jobLocker, nil)
+	// jobScheduler.Start(context.Background()) y jobScheduler.Stop en el apagado
+	// elegante de cmd/api se conectan una vez cfg.Database esté disponible aquí.
+	// =========================================================================
+
 	// =========================================================================
 	// Inicialización de Middlewares
 	rateLimiter := NewRateLimiter(1000, 1000, time.Second)
-	circuitBreaker := NewCircuitBreaker(20, 2*time.Second)
+	rateLimiter.RouteFunc = func(r *http.Request) string {
+		return r.Method + " " + r.URL.Path
+	}
+	rateLimiter.SetRoutePolicy("POST /users", RateLimitPolicy{Capacity: 20, Refill: 20, Per: time.Minute})
+	// rateLimiter.External = nil // CUIDADO: aquí se conectaría un ExternalDecider (p. ej. un bouncer CrowdSec)
+	circuitBreaker := NewCircuitBreakerWithKeyFunc(DefaultBreakerConfig(), func(r *http.Request) string {
+		return r.Method + " " + r.URL.Path
+	})
+	readOnlyMode := NewReadOnlyMode(false) // CUIDADO: cfg.Server.ReadOnly no disponible aquí todavía; cmd/api la conectaría también a un config.Watch ReloadHooks para poder alternarla sin redeploy
 	// =========================================================================
 
 	// Middlewares (orden importa)
@@ -50,13 +165,26 @@ func NewRouter() http.Handler {
 	h = withTimeouts(h)
 	h = rateLimiter.Middleware(h)
 	h = circuitBreaker.Middleware(h)
+	h = readOnlyMode.Middleware(h)
+	h = withAudit(auditor)(h)
 	h = withMetrics(h)
 	h = withTracing(h)
 
 	// Rutas
 	r.GET("/healthz", handlers.Healthz)
+	r.GET("/readyz", handlers.Readyz)
+	r.GET("/users", newListUsersHandler(userRepo))
 	r.GET("/users/:id", userHandler.GetUser)
 	r.POST("/users", userHandler.CreateUser)
+	r.GET("/exports/users", newStreamUsersHandler(userRepo))
+	r.Handler(http.MethodGet, "/audit", withOAuth(oauthServer, "audit:read")(newListAuditHandler(auditor)))
+	r.GET("/policies", newListPoliciesHandler(policyStore))
+	r.POST("/policies", newCreatePolicyHandler(policyStore, jobScheduler))
+	r.PUT("/policies/:id", newUpdatePolicyHandler(policyStore, jobScheduler))
+	r.POST("/policies/:id/enable", newSetPolicyEnabledHandler(policyStore, jobScheduler, true))
+	r.POST("/policies/:id/disable", newSetPolicyEnabledHandler(policyStore, jobScheduler, false))
+	r.POST("/policies/:id/trigger", newTriggerPolicyHandler(jobScheduler))
+	r.GET("/policies/:id/executions", newListExecutionsHandler(executionStore))
 	r.GET("/files/*path", handlers.ServeStatic) // zero-copy
 
 	return h
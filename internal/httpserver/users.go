@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/httpserver/users.go
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"highperf-api/internal/encoding/jsonx"
+	"highperf-api/internal/models"
+	"highperf-api/internal/repository"
+)
+
+// newListUsersHandler builds GET /users: it parses filter/sort/pagination
+// query parameters into a repository.ListOptions, runs the query, and
+// writes the page back as a models.UserListResponse with X-Total-Count and
+// Link headers set by Paginator. It lives here rather than in
+// internal/handlers so it can use Paginator without handlers importing
+// httpserver, which already imports handlers.
+func newListUsersHandler(repo repository.UserRepository) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		opts, err := parseListOptions(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		users, total, err := repo.List(r.Context(), opts)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		Paginator{Total: total, Page: opts.Page, PageSize: opts.PageSize}.WriteHeaders(w, r.URL)
+
+		resp := &models.UserListResponse{
+			Users:      make([]*models.UserResponse, len(users)),
+			Total:      total,
+			Page:       opts.Page,
+			PerPage:    opts.PageSize,
+			TotalPages: Paginator{Total: total, PageSize: opts.PageSize}.TotalPages(),
+		}
+		for i, u := range users {
+			resp.Users[i] = u.ToResponse()
+		}
+
+		_ = jsonx.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// parseListOptions builds a repository.ListOptions from GET /users query
+// parameters:
+//
+//	email, name              - substring filters
+//	created_after/_before    - RFC 3339 timestamps
+//	sort                     - comma-separated fields, "-field" for DESC
+//	page, page_size          - 1-based page number and page size
+func parseListOptions(q map[string][]string) (repository.ListOptions, error) {
+	get := func(key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	var opts repository.ListOptions
+	opts.Filters.EmailContains = get("email")
+	opts.Filters.NameContains = get("name")
+
+	if v := get("created_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, errBadQueryParam("created_after")
+		}
+		opts.Filters.CreatedAfter = t
+	}
+	if v := get("created_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, errBadQueryParam("created_before")
+		}
+		opts.Filters.CreatedBefore = t
+	}
+
+	if v := get("sort"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			desc := strings.HasPrefix(field, "-")
+			opts.Sort = append(opts.Sort, repository.SortField{
+				Field: strings.TrimPrefix(field, "-"),
+				Desc:  desc,
+			})
+		}
+	}
+
+	opts.Page = 1
+	if v := get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return opts, errBadQueryParam("page")
+		}
+		opts.Page = n
+	}
+	opts.PageSize = 20
+	if v := get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			return opts, errBadQueryParam("page_size")
+		}
+		opts.PageSize = n
+	}
+
+	return opts, nil
+}
+
+func errBadQueryParam(name string) error {
+	return &badQueryParamError{name}
+}
+
+type badQueryParamError struct{ name string }
+
+func (e *badQueryParamError) Error() string {
+	return "invalid query parameter: " + e.name
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"io"
+	"log"
 	"log/slog"
 	"os"
 	"time"
@@ -10,12 +11,19 @@ import (
 
 type Logger struct {
 	*slog.Logger
+	tap    *hub
+	fanout *fanoutHandler // nil when no extra Sinks are configured
 }
 
 type Config struct {
 	Level     string `json:"level" envconfig:"LOG_LEVEL" default:"info"`
 	Format    string `json:"format" envconfig:"LOG_FORMAT" default:"json"` // json, text
 	AddSource bool   `json:"add_source" envconfig:"LOG_ADD_SOURCE" default:"true"`
+
+	// Sinks are additional destinations every record is forwarded to
+	// alongside stdout (see Sink, fanoutHandler). Each has its own bounded
+	// queue, so a stalled one can't slow down the others or the caller.
+	Sinks []SinkConfig `json:"sinks"`
 }
 
 // New creates a new structured logger
@@ -39,15 +47,29 @@ func New(cfg Config) *Logger {
 		AddSource: cfg.AddSource,
 	}
 
-	var handler slog.Handler
+	var stdout slog.Handler
 	if cfg.Format == "text" {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		stdout = slog.NewTextHandler(os.Stdout, opts)
 	} else {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		stdout = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	var sinks []*queuedSink
+	for _, sc := range cfg.Sinks {
+		sink, err := buildSink(sc)
+		if err != nil {
+			log.Printf("logger: skipping sink %q: %v", sc.Name, err)
+			continue
+		}
+		sinks = append(sinks, newQueuedSink(sink, sc.QueueSize))
 	}
 
+	fanout := &fanoutHandler{stdout: stdout, sinks: sinks}
+	tap := newHub()
 	return &Logger{
-		Logger: slog.New(handler),
+		Logger: slog.New(&teeHandler{inner: fanout, hub: tap}),
+		tap:    tap,
+		fanout: fanout,
 	}
 }
 
@@ -56,8 +78,18 @@ func NewForTesting() *Logger {
 	handler := slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	})
+	tap := newHub()
 	return &Logger{
-		Logger: slog.New(handler),
+		Logger: slog.New(&teeHandler{inner: handler, hub: tap}),
+		tap:    tap,
+	}
+}
+
+// Close stops every configured Sink, flushing what's already queued. Safe to
+// call on a Logger built without any Sinks (Config.Sinks empty).
+func (l *Logger) Close() {
+	if l.fanout != nil {
+		l.fanout.Close()
 	}
 }
 
@@ -65,6 +97,8 @@ func NewForTesting() *Logger {
 func (l *Logger) WithRequestID(ctx context.Context, requestID string) *Logger {
 	return &Logger{
 		Logger: l.Logger.With("request_id", requestID),
+		tap:    l.tap,
+		fanout: l.fanout,
 	}
 }
 
@@ -72,6 +106,8 @@ func (l *Logger) WithRequestID(ctx context.Context, requestID string) *Logger {
 func (l *Logger) WithError(err error) *Logger {
 	return &Logger{
 		Logger: l.Logger.With("error", err.Error()),
+		tap:    l.tap,
+		fanout: l.fanout,
 	}
 }
 
@@ -83,12 +119,15 @@ func (l *Logger) WithFields(fields map[string]any) *Logger {
 	}
 	return &Logger{
 		Logger: l.Logger.With(args...),
+		tap:    l.tap,
+		fanout: l.fanout,
 	}
 }
 
-// HTTPRequest logs HTTP request details
-func (l *Logger) HTTPRequest(method, path, userAgent, clientIP string, statusCode int, duration time.Duration, bodySize int64) {
-	l.Info("http_request",
+// HTTPRequest logs HTTP request details. ctx carries trace correlation (see
+// internal/logger.TraceContext) through to any configured Sink.
+func (l *Logger) HTTPRequest(ctx context.Context, method, path, userAgent, clientIP string, statusCode int, duration time.Duration, bodySize int64) {
+	l.InfoContext(ctx, "http_request",
 		"method", method,
 		"path", path,
 		"user_agent", userAgent,
@@ -99,9 +138,10 @@ func (l *Logger) HTTPRequest(method, path, userAgent, clientIP string, statusCod
 	)
 }
 
-// HTTPError logs HTTP error details
-func (l *Logger) HTTPError(method, path string, statusCode int, err error, duration time.Duration) {
-	l.Error("http_error",
+// HTTPError logs HTTP error details. ctx carries trace correlation through
+// to any configured Sink.
+func (l *Logger) HTTPError(ctx context.Context, method, path string, statusCode int, err error, duration time.Duration) {
+	l.ErrorContext(ctx, "http_error",
 		"method", method,
 		"path", path,
 		"status_code", statusCode,
@@ -110,17 +150,19 @@ func (l *Logger) HTTPError(method, path string, statusCode int, err error, durat
 	)
 }
 
-// BusinessEvent logs business logic events
-func (l *Logger) BusinessEvent(event string, fields map[string]any) {
+// BusinessEvent logs business logic events. ctx carries trace correlation
+// through to any configured Sink.
+func (l *Logger) BusinessEvent(ctx context.Context, event string, fields map[string]any) {
 	args := []any{"event", event}
 	for k, v := range fields {
 		args = append(args, k, v)
 	}
-	l.Info("business_event", args...)
+	l.InfoContext(ctx, "business_event", args...)
 }
 
-// Performance logs performance metrics
-func (l *Logger) Performance(operation string, duration time.Duration, fields map[string]any) {
+// Performance logs performance metrics. ctx carries trace correlation
+// through to any configured Sink.
+func (l *Logger) Performance(ctx context.Context, operation string, duration time.Duration, fields map[string]any) {
 	args := []any{
 		"operation", operation,
 		"duration_ms", duration.Milliseconds(),
@@ -128,5 +170,5 @@ func (l *Logger) Performance(operation string, duration time.Duration, fields ma
 	for k, v := range fields {
 		args = append(args, k, v)
 	}
-	l.Info("performance", args...)
-}
\ No newline at end of file
+	l.InfoContext(ctx, "performance", args...)
+}
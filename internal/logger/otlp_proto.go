@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/otlp_proto.go
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// toDisplayString renders an attribute value AnyValue's oneof has no direct
+// case for (durations, errors, slices, ...) the same way slog's text
+// handler would.
+func toDisplayString(v any) string { return fmt.Sprint(v) }
+
+// This file hand-encodes just enough of the OTLP logs protobuf schema
+// (opentelemetry.proto.logs.v1 / .../collector/logs/v1, field numbers taken
+// from the published proto definitions) to build an
+// ExportLogsServiceRequest. Pulling in the generated opentelemetry-proto-go
+// module for four message types felt like a worse trade than ~100 lines of
+// wire-format encoding with no external dependency beyond the gRPC
+// transport itself.
+
+// pbWriter appends protobuf wire-format bytes. Zero value is ready to use.
+type pbWriter struct{ buf []byte }
+
+func (w *pbWriter) varint(v uint64) {
+	for v >= 0x80 {
+		w.buf = append(w.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *pbWriter) tag(field int, wireType byte) {
+	w.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (w *pbWriter) varintField(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	w.tag(field, 0)
+	w.varint(v)
+}
+
+func (w *pbWriter) fixed64Field(field int, v uint64) {
+	w.tag(field, 1)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	w.buf = append(w.buf, b[:]...)
+}
+
+func (w *pbWriter) bytesField(field int, b []byte) {
+	w.tag(field, 2)
+	w.varint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *pbWriter) stringField(field int, s string) {
+	if s == "" {
+		return
+	}
+	w.bytesField(field, []byte(s))
+}
+
+// embeddedField writes a length-delimited field whose payload is itself an
+// encoded message.
+func (w *pbWriter) embeddedField(field int, msg []byte) {
+	w.bytesField(field, msg)
+}
+
+// anyValueBytes encodes v as an OTLP AnyValue message, falling back to its
+// string representation for types without a dedicated oneof case.
+func anyValueBytes(v any) []byte {
+	w := &pbWriter{}
+	switch val := v.(type) {
+	case string:
+		w.stringField(1, val) // string_value
+	case bool:
+		w.tag(2, 0) // bool_value
+		if val {
+			w.varint(1)
+		} else {
+			w.varint(0)
+		}
+	case int64:
+		w.tag(3, 0) // int_value
+		w.varint(uint64(val))
+	case int:
+		w.tag(3, 0)
+		w.varint(uint64(int64(val)))
+	case float64:
+		w.tag(4, 1) // double_value
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(val))
+		w.buf = append(w.buf, b[:]...)
+	default:
+		w.stringField(1, toDisplayString(val))
+	}
+	return w.buf
+}
+
+// keyValueBytes encodes an OTLP KeyValue message.
+func keyValueBytes(key string, v any) []byte {
+	w := &pbWriter{}
+	w.stringField(1, key)
+	w.embeddedField(2, anyValueBytes(v))
+	return w.buf
+}
+
+// otlpLogRecord is the subset of an OTLP LogRecord this sink populates.
+type otlpLogRecord struct {
+	timeUnixNano uint64
+	severity     int32
+	severityText string
+	body         string
+	attrs        []otlpAttr
+	traceID      []byte // 16 bytes, nil if unavailable
+	spanID       []byte // 8 bytes, nil if unavailable
+}
+
+type otlpAttr struct {
+	key string
+	val any
+}
+
+func (rec otlpLogRecord) encode() []byte {
+	w := &pbWriter{}
+	w.fixed64Field(1, rec.timeUnixNano)
+	w.varintField(2, uint64(rec.severity))
+	w.stringField(3, rec.severityText)
+	if rec.body != "" {
+		w.embeddedField(5, anyValueBytes(rec.body))
+	}
+	for _, a := range rec.attrs {
+		w.embeddedField(6, keyValueBytes(a.key, a.val))
+	}
+	if len(rec.traceID) > 0 {
+		w.bytesField(9, rec.traceID)
+	}
+	if len(rec.spanID) > 0 {
+		w.bytesField(10, rec.spanID)
+	}
+	return w.buf
+}
+
+// encodeExportLogsServiceRequest builds the full request: one Resource
+// (service.name) and one InstrumentationScope ("highperf-api/logger")
+// wrapping every record in the batch.
+func encodeExportLogsServiceRequest(serviceName string, records []otlpLogRecord) []byte {
+	resource := &pbWriter{}
+	resource.embeddedField(1, keyValueBytes("service.name", serviceName))
+
+	scope := &pbWriter{}
+	scope.stringField(1, "highperf-api/logger")
+
+	scopeLogs := &pbWriter{}
+	scopeLogs.embeddedField(1, scope.buf)
+	for _, rec := range records {
+		scopeLogs.embeddedField(2, rec.encode())
+	}
+
+	resourceLogs := &pbWriter{}
+	resourceLogs.embeddedField(1, resource.buf)
+	resourceLogs.embeddedField(2, scopeLogs.buf)
+
+	req := &pbWriter{}
+	req.embeddedField(1, resourceLogs.buf)
+	return req.buf
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/sink.go
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+)
+
+// Sink is an additional destination for log records, on top of the always-on
+// stdout handler built by New. Each built-in sink (file, syslog, otlp) is
+// itself a slog.Handler so it can keep whatever per-destination formatting
+// state WithAttrs/WithGroup need (e.g. a syslog hostname/app-name pair).
+type Sink interface {
+	slog.Handler
+	// Name identifies the sink in logs about the sink itself (queue-full
+	// warnings, connection errors), not in the records it forwards.
+	Name() string
+	// Close releases any connection/file held by the sink. The OTLP sink
+	// exports its pending batch before closing its connection.
+	Close() error
+}
+
+// SinkConfig describes one configured Sink. Exactly one of File, Syslog, or
+// OTLP should be set, matching Type.
+type SinkConfig struct {
+	Name      string `json:"name"`
+	Type      string `json:"type" envconfig:"TYPE"` // file, syslog, otlp
+	QueueSize int    `json:"queue_size" envconfig:"QUEUE_SIZE" default:"256"`
+
+	File   *FileSinkConfig   `json:"file,omitempty"`
+	Syslog *SyslogSinkConfig `json:"syslog,omitempty"`
+	OTLP   *OTLPSinkConfig   `json:"otlp,omitempty"`
+}
+
+// buildSink constructs the Sink described by cfg. A build failure (e.g. the
+// log directory isn't writable, or the OTLP endpoint can't be dialed) is
+// reported to the caller rather than panicking New, so one misconfigured
+// sink doesn't take down the whole logger.
+func buildSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "file":
+		return newFileSink(cfg.Name, cfg.File)
+	case "syslog":
+		return newSyslogSink(cfg.Name, cfg.Syslog)
+	case "otlp":
+		return newOTLPSink(cfg.Name, cfg.OTLP)
+	default:
+		return nil, &unknownSinkTypeError{cfg.Type}
+	}
+}
+
+type unknownSinkTypeError struct{ typ string }
+
+func (e *unknownSinkTypeError) Error() string { return "logger: unknown sink type " + e.typ }
+
+// queuedSink decouples a Sink from the logging caller with a bounded,
+// drop-oldest queue drained by one background goroutine, so a slow or
+// stalled destination (a wedged TCP syslog connection, a collector that
+// stopped ack'ing) never blocks request-handling goroutines.
+type queuedSink struct {
+	sink  Sink
+	queue chan queuedRecord
+	done  chan struct{}
+}
+
+type queuedRecord struct {
+	ctx context.Context
+	r   slog.Record
+}
+
+func newQueuedSink(sink Sink, queueSize int) *queuedSink {
+	if queueSize <= 0 {
+		queueSize = 256
+	}
+	qs := &queuedSink{
+		sink:  sink,
+		queue: make(chan queuedRecord, queueSize),
+		done:  make(chan struct{}),
+	}
+	go qs.run()
+	return qs
+}
+
+func (qs *queuedSink) run() {
+	defer close(qs.done)
+	for qr := range qs.queue {
+		if err := qs.sink.Handle(qr.ctx, qr.r); err != nil {
+			log.Printf("logger: sink %q: %v", qs.sink.Name(), err)
+		}
+	}
+}
+
+// enqueue publishes r without blocking the caller. When the queue is full,
+// the oldest queued record is dropped to make room, consistent with the
+// drop-oldest policy the live Tap subscriptions use.
+func (qs *queuedSink) enqueue(ctx context.Context, r slog.Record) {
+	qr := queuedRecord{ctx: ctx, r: r}
+	select {
+	case qs.queue <- qr:
+		return
+	default:
+	}
+	select {
+	case <-qs.queue:
+	default:
+	}
+	select {
+	case qs.queue <- qr:
+	default:
+	}
+}
+
+// stop drains and closes the sink. It blocks until the background goroutine
+// has processed everything already queued.
+func (qs *queuedSink) stop() {
+	close(qs.queue)
+	<-qs.done
+	if err := qs.sink.Close(); err != nil {
+		log.Printf("logger: closing sink %q: %v", qs.sink.Name(), err)
+	}
+}
+
+// fanoutHandler is the root slog.Handler built by New: it handles every
+// record on the stdout handler synchronously (so local log lines keep their
+// current ordering/latency guarantees) and additionally, concurrently,
+// enqueues the record onto every configured Sink's bounded queue.
+//
+// The queue + draining goroutine behind each Sink (see queuedSink) is
+// created once, in New, and shared by every handler value derived from this
+// one via WithAttrs/WithGroup — a naive implementation that spun up a fresh
+// queue per derivation would leak a goroutine on every WithRequestID-style
+// call. Because of that sharing, attrs/groups attached via With(...) can't
+// be handed to the Sink's own WithAttrs (there is no single underlying Sink
+// instance to hand them to anymore); instead fanoutHandler tracks them
+// itself and merges them into the record before it reaches a sink. Nested
+// groups are flattened into dotted attribute keys for this merged copy —
+// the stdout handler, which owns its own WithAttrs chain, still renders
+// groups correctly; only the sink path simplifies them.
+type fanoutHandler struct {
+	stdout slog.Handler
+	sinks  []*queuedSink
+
+	groupPrefix string
+	extra       []slog.Attr
+}
+
+func (f *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return f.stdout.Enabled(ctx, level)
+}
+
+func (f *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(f.sinks) > 0 {
+		out := r.Clone()
+		if f.groupPrefix != "" {
+			out = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+			r.Attrs(func(a slog.Attr) bool {
+				a.Key = f.groupPrefix + "." + a.Key
+				out.AddAttrs(a)
+				return true
+			})
+		}
+		out.AddAttrs(f.extra...)
+		for _, qs := range f.sinks {
+			if qs.sink.Enabled(ctx, r.Level) {
+				qs.enqueue(ctx, out)
+			}
+		}
+	}
+	return f.stdout.Handle(ctx, r)
+}
+
+func (f *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefixed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		if f.groupPrefix != "" {
+			a.Key = f.groupPrefix + "." + a.Key
+		}
+		prefixed[i] = a
+	}
+	return &fanoutHandler{
+		stdout:      f.stdout.WithAttrs(attrs),
+		sinks:       f.sinks,
+		groupPrefix: f.groupPrefix,
+		extra:       append(append([]slog.Attr{}, f.extra...), prefixed...),
+	}
+}
+
+func (f *fanoutHandler) WithGroup(name string) slog.Handler {
+	groupPrefix := name
+	if f.groupPrefix != "" {
+		groupPrefix = f.groupPrefix + "." + name
+	}
+	return &fanoutHandler{
+		stdout:      f.stdout.WithGroup(name),
+		sinks:       f.sinks,
+		groupPrefix: groupPrefix,
+		extra:       f.extra,
+	}
+}
+
+// Close stops every configured sink, flushing their queues first.
+func (f *fanoutHandler) Close() {
+	for _, qs := range f.sinks {
+		qs.stop()
+	}
+}
@@ -0,0 +1,292 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/sink_otlp.go
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// otlpExportMethod is the unary RPC OTLP/gRPC log exporters speak, per the
+// OpenTelemetry protocol specification.
+const otlpExportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+func init() {
+	encoding.RegisterCodec(rawBytesCodec{})
+}
+
+// rawBytesCodec lets this sink invoke the Export RPC with protobuf bytes it
+// already encoded itself (otlp_proto.go), without depending on the
+// generated opentelemetry-proto message types.
+type rawBytesCodec struct{}
+
+func (rawBytesCodec) Name() string { return "raw-otlp" }
+
+func (rawBytesCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("logger: rawBytesCodec: unsupported type %T", v)
+	}
+	return b, nil
+}
+
+func (rawBytesCodec) Unmarshal(data []byte, v any) error {
+	p, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("logger: rawBytesCodec: unsupported type %T", v)
+	}
+	*p = append((*p)[:0], data...)
+	return nil
+}
+
+type rawMessage []byte
+
+// OTLPSinkConfig configures an OTLP/gRPC logs exporter.
+type OTLPSinkConfig struct {
+	Endpoint      string        `json:"endpoint" envconfig:"ENDPOINT"`
+	Insecure      bool          `json:"insecure" envconfig:"INSECURE" default:"false"`
+	ServiceName   string        `json:"service_name" envconfig:"SERVICE_NAME" default:"highperf-api"`
+	BatchSize     int           `json:"batch_size" envconfig:"BATCH_SIZE" default:"256"`
+	BatchTimeout  time.Duration `json:"batch_timeout" envconfig:"BATCH_TIMEOUT" default:"5s"`
+	MaxRetries    int           `json:"max_retries" envconfig:"MAX_RETRIES" default:"3"`
+	RetryBaseWait time.Duration `json:"retry_base_wait" envconfig:"RETRY_BASE_WAIT" default:"200ms"`
+}
+
+// otlpSink batches records and ships them to an OTLP collector over gRPC.
+// Batches flush on size (cfg.BatchSize) or time (cfg.BatchTimeout),
+// whichever comes first, and a failed export is retried with exponential
+// backoff before the batch is dropped.
+type otlpSink struct {
+	name string
+	cfg  OTLPSinkConfig
+	conn *grpc.ClientConn
+
+	mu      sync.Mutex
+	pending []otlpLogRecord
+
+	flushNow chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+func newOTLPSink(name string, cfg *OTLPSinkConfig) (Sink, error) {
+	if cfg == nil || cfg.Endpoint == "" {
+		return nil, fmt.Errorf("logger: otlp sink %q: endpoint is required", name)
+	}
+	c := *cfg
+	if c.ServiceName == "" {
+		c.ServiceName = "highperf-api"
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 256
+	}
+	if c.BatchTimeout <= 0 {
+		c.BatchTimeout = 5 * time.Second
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryBaseWait <= 0 {
+		c.RetryBaseWait = 200 * time.Millisecond
+	}
+
+	var creds credentials.TransportCredentials
+	if c.Insecure {
+		creds = insecure.NewCredentials()
+	} else {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(c.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("logger: otlp sink %q: %w", name, err)
+	}
+
+	s := &otlpSink{
+		name:     name,
+		cfg:      c,
+		conn:     conn,
+		flushNow: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *otlpSink) Name() string { return s.name }
+
+func (s *otlpSink) Enabled(context.Context, slog.Level) bool { return true }
+
+func (s *otlpSink) Handle(ctx context.Context, r slog.Record) error {
+	rec := otlpLogRecord{
+		timeUnixNano: uint64(r.Time.UnixNano()),
+		severity:     otlpSeverity(r.Level),
+		severityText: r.Level.String(),
+		body:         r.Message,
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.attrs = append(rec.attrs, otlpAttr{key: a.Key, val: a.Value.Any()})
+		// trace_id/span_id are promoted to top-level LogRecord
+		// fields in addition to staying as attributes, so a backend that
+		// only understands the well-known fields still correlates.
+		switch a.Key {
+		case "trace_id":
+			rec.traceID = decodeHexID(a.Value.String(), 16)
+		case "span_id":
+			rec.spanID = decodeHexID(a.Value.String(), 8)
+		}
+		return true
+	})
+	if rec.traceID == nil || rec.spanID == nil {
+		if tc, ok := TraceContextFromContext(ctx); ok {
+			if rec.traceID == nil {
+				rec.traceID = decodeHexID(tc.TraceID, 16)
+			}
+			if rec.spanID == nil {
+				rec.spanID = decodeHexID(tc.SpanID, 8)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, rec)
+	full := len(s.pending) >= s.cfg.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushNow <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *otlpSink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.cfg.BatchTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushNow:
+			s.flush()
+		}
+	}
+}
+
+func (s *otlpSink) flush() {
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if err := s.export(batch); err != nil {
+		log.Printf("logger: otlp sink %q: dropping batch of %d records: %v", s.name, len(batch), err)
+	}
+}
+
+// export sends batch, retrying transient gRPC failures with exponential
+// backoff (cfg.RetryBaseWait, cfg.RetryBaseWait*2, ...) up to cfg.MaxRetries
+// times.
+func (s *otlpSink) export(batch []otlpLogRecord) error {
+	req := rawMessage(encodeExportLogsServiceRequest(s.cfg.ServiceName, batch))
+	var resp rawMessage
+
+	var lastErr error
+	wait := s.cfg.RetryBaseWait
+	for attempt := 0; attempt <= s.cfg.MaxRetries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.conn.Invoke(ctx, otlpExportMethod, req, &resp, grpc.CallContentSubtype(rawBytesCodec{}.Name()))
+		cancel()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !isRetryable(err) || attempt == s.cfg.MaxRetries {
+			break
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+	return lastErr
+}
+
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *otlpSink) WithAttrs(attrs []slog.Attr) slog.Handler { return s }
+func (s *otlpSink) WithGroup(name string) slog.Handler       { return s }
+
+func (s *otlpSink) Close() error {
+	close(s.stop)
+	<-s.stopped
+	return s.conn.Close()
+}
+
+// otlpSeverity maps a slog.Level onto the closest OTLP SeverityNumber.
+func otlpSeverity(level slog.Level) int32 {
+	switch {
+	case level >= slog.LevelError:
+		return 17 // SEVERITY_NUMBER_ERROR
+	case level >= slog.LevelWarn:
+		return 13 // SEVERITY_NUMBER_WARN
+	case level >= slog.LevelInfo:
+		return 9 // SEVERITY_NUMBER_INFO
+	default:
+		return 5 // SEVERITY_NUMBER_DEBUG
+	}
+}
+
+// decodeHexID decodes a hex-encoded trace/span id, returning nil unless it's
+// exactly wantLen bytes once decoded (OTLP requires fixed-width ids).
+func decodeHexID(s string, wantLen int) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != wantLen {
+		return nil
+	}
+	return b
+}
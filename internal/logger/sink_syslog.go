@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/sink_syslog.go
+package logger
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogSinkConfig configures an RFC 5424 syslog sink.
+type SyslogSinkConfig struct {
+	Network  string        `json:"network" envconfig:"NETWORK" default:"udp"` // udp, tcp, tls
+	Address  string        `json:"address" envconfig:"ADDRESS"`
+	AppName  string        `json:"app_name" envconfig:"APP_NAME" default:"highperf-api"`
+	Facility int           `json:"facility" envconfig:"FACILITY" default:"16"` // local0
+	Dial     time.Duration `json:"dial_timeout" envconfig:"DIAL_TIMEOUT" default:"5s"`
+}
+
+// syslogSink formats records as RFC 5424 messages and ships them over a
+// UDP, TCP, or TLS connection. The connection is dialed lazily and
+// redialed on write failure, since syslog collectors routinely bounce.
+type syslogSink struct {
+	name     string
+	cfg      SyslogSinkConfig
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogSink(name string, cfg *SyslogSinkConfig) (Sink, error) {
+	if cfg == nil || cfg.Address == "" {
+		return nil, fmt.Errorf("logger: syslog sink %q: address is required", name)
+	}
+	switch cfg.Network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("logger: syslog sink %q: unsupported network %q", name, cfg.Network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &syslogSink{name: name, cfg: *cfg, hostname: hostname}, nil
+}
+
+func (s *syslogSink) Name() string { return s.name }
+
+func (s *syslogSink) Enabled(context.Context, slog.Level) bool { return true }
+
+func (s *syslogSink) connLocked() (net.Conn, error) {
+	if s.conn != nil {
+		return s.conn, nil
+	}
+	var conn net.Conn
+	var err error
+	switch s.cfg.Network {
+	case "tls":
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: s.cfg.Dial}, "tcp", s.cfg.Address, &tls.Config{MinVersion: tls.VersionTLS12})
+	default:
+		conn, err = net.DialTimeout(s.cfg.Network, s.cfg.Address, s.cfg.Dial)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+func (s *syslogSink) Handle(ctx context.Context, r slog.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conn, err := s.connLocked()
+	if err != nil {
+		return fmt.Errorf("logger: syslog sink %q: dial: %w", s.name, err)
+	}
+
+	msg := s.formatRFC5424(ctx, r)
+	if _, err := conn.Write(msg); err != nil {
+		// The collector may have closed an idle connection; redial once.
+		s.conn = nil
+		conn, dialErr := s.connLocked()
+		if dialErr != nil {
+			return fmt.Errorf("logger: syslog sink %q: redial: %w", s.name, dialErr)
+		}
+		if _, err := conn.Write(msg); err != nil {
+			s.conn = nil
+			return fmt.Errorf("logger: syslog sink %q: write: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+// formatRFC5424 renders r as a single RFC 5424 syslog message:
+// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *syslogSink) formatRFC5424(ctx context.Context, r slog.Record) []byte {
+	pri := s.cfg.Facility*8 + severityFor(r.Level)
+	ts := r.Time.UTC().Format(time.RFC3339Nano)
+
+	var sd strings.Builder
+	sd.WriteString("[meta")
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&sd, " %s=%q", sdSafeParamName(a.Key), a.Value.String())
+		return true
+	})
+	if tc, ok := TraceContextFromContext(ctx); ok {
+		fmt.Fprintf(&sd, " trace_id=%q span_id=%q", tc.TraceID, tc.SpanID)
+	}
+	sd.WriteString("]")
+
+	line := fmt.Sprintf("<%d>1 %s %s %s %d - %s %s\n",
+		pri, ts, s.hostname, s.cfg.AppName, os.Getpid(), sd.String(), r.Message)
+
+	if s.cfg.Network == "udp" {
+		return []byte(line)
+	}
+	// Stream transports need octet-counting framing (RFC 6587) so a
+	// collector can tell where one message ends and the next begins.
+	return []byte(fmt.Sprintf("%d %s", len(line), line))
+}
+
+// sdSafeParamName replaces characters RFC 5424 structured-data param names
+// disallow ('=', ']', '"', space, control chars) with '_' so an attribute
+// key can't break the framing.
+func sdSafeParamName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '=', ']', '"', ' ':
+			return '_'
+		}
+		if r < 0x20 {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// severityFor maps a slog.Level onto the closest RFC 5424 severity.
+func severityFor(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // error
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+func (s *syslogSink) WithAttrs(attrs []slog.Attr) slog.Handler { return s }
+func (s *syslogSink) WithGroup(name string) slog.Handler       { return s }
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/tap.go
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription is one registered tap on a Logger's record stream. Records
+// are delivered best-effort: a slow subscriber drops its oldest buffered
+// record rather than blocking the logger's caller.
+type Subscription struct {
+	ch      chan slog.Record
+	dropped atomic.Int64
+}
+
+// C returns the channel records are delivered on. It is closed once the
+// unsubscribe function returned by Logger.Tap is called.
+func (s *Subscription) C() <-chan slog.Record { return s.ch }
+
+// Dropped reports how many records this subscription has lost to
+// backpressure since it was created.
+func (s *Subscription) Dropped() int64 { return s.dropped.Load() }
+
+func (s *Subscription) publish(r slog.Record) {
+	select {
+	case s.ch <- r:
+		return
+	default:
+	}
+	// Drop-oldest: make room for r rather than blocking the producer or
+	// silently dropping the newest record instead.
+	select {
+	case <-s.ch:
+	default:
+	}
+	select {
+	case s.ch <- r:
+	default:
+	}
+	s.dropped.Add(1)
+}
+
+// hub fans a record out to every live Subscription. It is shared by every
+// Logger derived from the same root (WithFields, WithRequestID, ...) so a
+// tap survives attribute chaining.
+type hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func newHub() *hub { return &hub{subs: make(map[*Subscription]struct{})} }
+
+func (h *hub) publish(r slog.Record) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		s.publish(r)
+	}
+}
+
+func (h *hub) subscribe(bufferSize int) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+	sub := &Subscription{ch: make(chan slog.Record, bufferSize)}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+	close(sub.ch)
+}
+
+// teeHandler wraps a slog.Handler, publishing every handled Record to hub
+// in addition to passing it through to inner.
+type teeHandler struct {
+	inner slog.Handler
+	hub   *hub
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t.inner.Enabled(ctx, level)
+}
+
+func (t *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	t.hub.publish(r.Clone())
+	return t.inner.Handle(ctx, r)
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &teeHandler{inner: t.inner.WithAttrs(attrs), hub: t.hub}
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	return &teeHandler{inner: t.inner.WithGroup(name), hub: t.hub}
+}
+
+// Tap registers a new Subscription on l's record stream, buffered to
+// bufferSize records (64 when <= 0). Call the returned function to
+// unsubscribe and release its channel.
+func (l *Logger) Tap(bufferSize int) (*Subscription, func()) {
+	sub := l.tap.subscribe(bufferSize)
+	return sub, func() { l.tap.unsubscribe(sub) }
+}
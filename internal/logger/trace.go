@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/logger/trace.go
+package logger
+
+import "context"
+
+// traceContextKey is the unexported context key for TraceContext, following
+// the same contextKey pattern used throughout internal/oauth and
+// internal/auth/jwt.
+type traceContextKey struct{}
+
+// TraceContext identifies the trace/span a log record was produced under.
+// It's deliberately independent of any particular tracing SDK: withTracing
+// (internal/httpserver/middleware.go) populates it per-request, and the
+// OTLP sink promotes it to the LogRecord's top-level trace_id/span_id
+// fields so traces and logs correlate in a backend that understands both.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// WithTraceContext attaches tc to ctx.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext retrieves the TraceContext attached by
+// WithTraceContext, if any.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
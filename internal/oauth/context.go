@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/oauth/context.go
+package oauth
+
+import "context"
+
+type contextKey int
+
+const userIDContextKey contextKey = iota
+
+// WithUserID attaches an already-authenticated user id to ctx, so
+// HandleAuthorize knows who is granting consent to the client. Whatever
+// sits in front of /oauth/authorize (the existing password-login session,
+// a connectors callback, ...) is responsible for calling this before
+// delegating to HandleAuthorize.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext retrieves the user id WithUserID attached, if any.
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDContextKey).(int64)
+	return id, ok
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/oauth/errors.go
+package oauth
+
+import (
+	"net/http"
+
+	"highperf-api/internal/encoding/jsonx"
+)
+
+// requestError is one of the error codes RFC 6749 §5.2 defines for the
+// token endpoint; §4.1.2.1 reuses the same set for the authorize redirect.
+type requestError string
+
+const (
+	errInvalidRequest       requestError = "invalid_request"
+	errInvalidClient        requestError = "invalid_client"
+	errInvalidGrant         requestError = "invalid_grant"
+	errUnauthorizedClient   requestError = "unauthorized_client"
+	errUnsupportedGrantType requestError = "unsupported_grant_type"
+	errInvalidScope         requestError = "invalid_scope"
+	errServerError          requestError = "server_error"
+)
+
+// httpStatus is the response status for each error: 401 for
+// invalid_client and unauthorized_client, 500 for server_error, and 400
+// for the rest.
+func (e requestError) httpStatus() int {
+	switch e {
+	case errInvalidClient, errUnauthorizedClient:
+		return http.StatusUnauthorized
+	case errServerError:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+type errorResponse struct {
+	Error            requestError `json:"error"`
+	ErrorDescription string       `json:"error_description,omitempty"`
+}
+
+// writeError sends the RFC 6749 §5.2 JSON error body for the token,
+// introspection, and revocation endpoints.
+func writeError(w http.ResponseWriter, code requestError, description string) {
+	w.Header().Set("Cache-Control", "no-store")
+	_ = jsonx.WriteJSON(w, code.httpStatus(), errorResponse{Error: code, ErrorDescription: description})
+}
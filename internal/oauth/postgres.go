@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/oauth/postgres.go
+package oauth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"highperf-api/internal/database"
+	"highperf-api/internal/logger"
+)
+
+// PostgresClientStore reads registered clients from a `oauth_clients` table
+// populated out of band (there's no self-service client registration yet).
+type PostgresClientStore struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresClientStore creates a ClientStore backed by Postgres.
+func NewPostgresClientStore(db *database.DB, log *logger.Logger) *PostgresClientStore {
+	return &PostgresClientStore{db: db, logger: log}
+}
+
+func (s *PostgresClientStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	query := `
+		SELECT id, secret_hash, redirect_uris, grant_types, scopes
+		FROM oauth_clients
+		WHERE id = $1`
+
+	var redirectURIs, grantTypes, scopes string
+	c := &Client{}
+
+	err := s.db.QueryRowContext(ctx, query, clientID).Scan(
+		&c.ID, &c.SecretHash, &redirectURIs, &grantTypes, &scopes,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		s.logger.WithError(err).Error("failed to look up oauth client", "client_id", clientID)
+		return nil, fmt.Errorf("failed to look up oauth client: %w", err)
+	}
+
+	c.RedirectURIs = splitNonEmpty(redirectURIs)
+	for _, g := range splitNonEmpty(grantTypes) {
+		c.GrantTypes = append(c.GrantTypes, GrantType(g))
+	}
+	c.Scopes = splitNonEmpty(scopes)
+
+	return c, nil
+}
+
+// PostgresAuthCodeStore persists authorization codes in `oauth_codes`.
+type PostgresAuthCodeStore struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresAuthCodeStore creates an AuthCodeStore backed by Postgres.
+func NewPostgresAuthCodeStore(db *database.DB, log *logger.Logger) *PostgresAuthCodeStore {
+	return &PostgresAuthCodeStore{db: db, logger: log}
+}
+
+func (s *PostgresAuthCodeStore) SaveCode(ctx context.Context, code *AuthCode) error {
+	query := `
+		INSERT INTO oauth_codes
+			(code, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		code.Code, code.ClientID, code.UserID, code.RedirectURI, code.Scope,
+		code.CodeChallenge, code.CodeChallengeMethod, code.ExpiresAt,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to save authorization code", "client_id", code.ClientID)
+		return fmt.Errorf("failed to save authorization code: %w", err)
+	}
+	return nil
+}
+
+// ConsumeCode atomically deletes and returns the code row, so a code can
+// never be exchanged twice even under concurrent requests.
+func (s *PostgresAuthCodeStore) ConsumeCode(ctx context.Context, code string) (*AuthCode, error) {
+	query := `
+		DELETE FROM oauth_codes
+		WHERE code = $1 AND expires_at > now()
+		RETURNING code, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, expires_at`
+
+	ac := &AuthCode{}
+	err := s.db.QueryRowContext(ctx, query, code).Scan(
+		&ac.Code, &ac.ClientID, &ac.UserID, &ac.RedirectURI, &ac.Scope,
+		&ac.CodeChallenge, &ac.CodeChallengeMethod, &ac.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCodeNotFound
+		}
+		s.logger.WithError(err).Error("failed to consume authorization code")
+		return nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+	return ac, nil
+}
+
+// PostgresTokenStore persists issued tokens in `oauth_tokens`, indexed by
+// signature for both the access and refresh token.
+type PostgresTokenStore struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresTokenStore creates a TokenStore backed by Postgres.
+func NewPostgresTokenStore(db *database.DB, log *logger.Logger) *PostgresTokenStore {
+	return &PostgresTokenStore{db: db, logger: log}
+}
+
+func (s *PostgresTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	query := `
+		INSERT INTO oauth_tokens
+			(access_signature, refresh_signature, client_id, user_id, scope, expires_at, refresh_expires_at, revoked)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, false)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		token.AccessSignature, token.RefreshSignature, token.ClientID, token.UserID,
+		token.Scope, token.ExpiresAt, token.RefreshExpiresAt,
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to save oauth token", "client_id", token.ClientID)
+		return fmt.Errorf("failed to save oauth token: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresTokenStore) GetByAccessSignature(ctx context.Context, sig string) (*Token, error) {
+	return s.getBy(ctx, "access_signature", sig)
+}
+
+func (s *PostgresTokenStore) GetByRefreshSignature(ctx context.Context, sig string) (*Token, error) {
+	return s.getBy(ctx, "refresh_signature", sig)
+}
+
+func (s *PostgresTokenStore) getBy(ctx context.Context, column, sig string) (*Token, error) {
+	// column is always one of the two literals above, never request input.
+	query := fmt.Sprintf(`
+		SELECT access_signature, refresh_signature, client_id, user_id, scope, expires_at, refresh_expires_at, revoked
+		FROM oauth_tokens
+		WHERE %s = $1`, column)
+
+	t := &Token{}
+	err := s.db.QueryRowContext(ctx, query, sig).Scan(
+		&t.AccessSignature, &t.RefreshSignature, &t.ClientID, &t.UserID,
+		&t.Scope, &t.ExpiresAt, &t.RefreshExpiresAt, &t.Revoked,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		s.logger.WithError(err).Error("failed to look up oauth token")
+		return nil, fmt.Errorf("failed to look up oauth token: %w", err)
+	}
+	return t, nil
+}
+
+func (s *PostgresTokenStore) Revoke(ctx context.Context, accessSignature string) error {
+	query := `UPDATE oauth_tokens SET revoked = true WHERE access_signature = $1`
+	if _, err := s.db.ExecContext(ctx, query, accessSignature); err != nil {
+		s.logger.WithError(err).Error("failed to revoke oauth token")
+		return fmt.Errorf("failed to revoke oauth token: %w", err)
+	}
+	return nil
+}
+
+// splitNonEmpty splits a comma-separated column value, dropping empty
+// fields left by a trailing/leading comma.
+func splitNonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,409 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/julienschmidt/httprouter"
+
+	"highperf-api/internal/auth"
+	"highperf-api/internal/models"
+	"highperf-api/internal/repository"
+)
+
+// ed25519KeySet is a minimal single-key auth.KeySet for tests, the same
+// shape as auth.FileKeySet but without the directory/rotation machinery -
+// the grant logic under test only needs something that round-trips
+// through sign/parseToken with a real crypto.Signer.
+type ed25519KeySet struct {
+	kid  string
+	priv ed25519.PrivateKey
+}
+
+func (k ed25519KeySet) Current() (string, crypto.Signer, jwt.SigningMethod) {
+	return k.kid, k.priv, jwt.SigningMethodEdDSA
+}
+
+func (k ed25519KeySet) Lookup(kid string) (crypto.PublicKey, jwt.SigningMethod, bool) {
+	if kid != k.kid {
+		return nil, nil, false
+	}
+	return k.priv.Public(), jwt.SigningMethodEdDSA, true
+}
+
+func testKeySet() auth.KeySet {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic(err)
+	}
+	return ed25519KeySet{kid: "test-eddsa", priv: priv}
+}
+
+// fakeClientStore is a fixed ClientStore for tests.
+type fakeClientStore map[string]*Client
+
+func (f fakeClientStore) GetClient(_ context.Context, clientID string) (*Client, error) {
+	c, ok := f[clientID]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+	return c, nil
+}
+
+// fakeCodeStore is an in-memory AuthCodeStore; ConsumeCode deletes the code
+// so a second exchange of the same code always misses, the same single-use
+// guarantee Postgres's DELETE ... RETURNING would give.
+type fakeCodeStore struct {
+	codes map[string]*AuthCode
+}
+
+func newFakeCodeStore() *fakeCodeStore { return &fakeCodeStore{codes: map[string]*AuthCode{}} }
+
+func (f *fakeCodeStore) SaveCode(_ context.Context, code *AuthCode) error {
+	f.codes[code.Code] = code
+	return nil
+}
+
+func (f *fakeCodeStore) ConsumeCode(_ context.Context, code string) (*AuthCode, error) {
+	c, ok := f.codes[code]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+	delete(f.codes, code)
+	return c, nil
+}
+
+// fakeTokenStore is an in-memory TokenStore indexed by both signatures, the
+// way the Postgres implementation is indexed by both columns.
+type fakeTokenStore struct {
+	byAccess  map[string]*Token
+	byRefresh map[string]*Token
+}
+
+func newFakeTokenStore() *fakeTokenStore {
+	return &fakeTokenStore{byAccess: map[string]*Token{}, byRefresh: map[string]*Token{}}
+}
+
+func (f *fakeTokenStore) SaveToken(_ context.Context, token *Token) error {
+	cp := *token
+	f.byAccess[token.AccessSignature] = &cp
+	f.byRefresh[token.RefreshSignature] = &cp
+	return nil
+}
+
+func (f *fakeTokenStore) GetByAccessSignature(_ context.Context, sig string) (*Token, error) {
+	t, ok := f.byAccess[sig]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return t, nil
+}
+
+func (f *fakeTokenStore) GetByRefreshSignature(_ context.Context, sig string) (*Token, error) {
+	t, ok := f.byRefresh[sig]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return t, nil
+}
+
+func (f *fakeTokenStore) Revoke(_ context.Context, accessSignature string) error {
+	if t, ok := f.byAccess[accessSignature]; ok {
+		t.Revoked = true
+	}
+	return nil
+}
+
+// fakeUserRepo implements repository.UserRepository with a single
+// hardcoded user, enough for grantPassword and HandleIntrospect's username
+// lookup; every other method is unused by the grant paths under test.
+type fakeUserRepo struct {
+	user *models.User
+}
+
+func (f *fakeUserRepo) Create(context.Context, *models.User) (*models.User, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeUserRepo) GetByID(_ context.Context, id int64) (*models.User, error) {
+	if f.user != nil && f.user.ID == id {
+		return f.user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+func (f *fakeUserRepo) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	if f.user != nil && f.user.Email == email {
+		return f.user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+func (f *fakeUserRepo) Update(context.Context, *models.User) (*models.User, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeUserRepo) Delete(context.Context, int64) error { return errors.New("not implemented") }
+func (f *fakeUserRepo) List(context.Context, repository.ListOptions) ([]*models.User, int, error) {
+	return nil, 0, errors.New("not implemented")
+}
+func (f *fakeUserRepo) UpsertFromIdentity(context.Context, string, string, string, string) (*models.User, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeUserRepo) ListAfterID(context.Context, int64, int) ([]*models.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+// testServer wires a Server from the fakes above plus a confidential test
+// client and, when withUser is true, a matching password-grant user.
+func testServer(t *testing.T, client *Client, withUser bool) (*Server, *fakeCodeStore, *fakeTokenStore) {
+	t.Helper()
+
+	hasher := auth.NewPasswordHasher()
+	var users repository.UserRepository = &fakeUserRepo{}
+	if withUser {
+		hash, err := hasher.HashPassword("correct-password")
+		if err != nil {
+			t.Fatalf("HashPassword failed: %v", err)
+		}
+		users = &fakeUserRepo{user: &models.User{ID: 1, Email: "user@example.com", PasswordHash: hash}}
+	}
+
+	codes := newFakeCodeStore()
+	tokens := newFakeTokenStore()
+	srv := NewServer(fakeClientStore{client.ID: client}, codes, tokens, users, testKeySet())
+	return srv, codes, tokens
+}
+
+func doToken(srv *Server, form url.Values) (*httptest.ResponseRecorder, map[string]any) {
+	req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	srv.HandleToken(w, req, httprouter.Params{})
+
+	var body map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func pkcePair(verifier string) (challenge string) {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func confidentialClient() *Client {
+	hasher := auth.NewPasswordHasher()
+	secretHash, _ := hasher.HashPassword("client-secret")
+	return &Client{
+		ID:           "confidential-client",
+		SecretHash:   secretHash,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []GrantType{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials, GrantPassword},
+		Scopes:       []string{"read", "write"},
+	}
+}
+
+func publicClient() *Client {
+	return &Client{
+		ID:           "public-client",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []GrantType{GrantAuthorizationCode},
+		Scopes:       []string{"read"},
+	}
+}
+
+func TestGrantAuthorizationCodePKCEMismatch(t *testing.T) {
+	client := publicClient()
+	srv, codes, _ := testServer(t, client, false)
+
+	_ = codes.SaveCode(context.Background(), &AuthCode{
+		Code:                "auth-code-1",
+		ClientID:            client.ID,
+		RedirectURI:         client.RedirectURIs[0],
+		CodeChallenge:       pkcePair("the-real-verifier"),
+		CodeChallengeMethod: "S256",
+	})
+
+	w, body := doToken(srv, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID},
+		"code":          {"auth-code-1"},
+		"redirect_uri":  {client.RedirectURIs[0]},
+		"code_verifier": {"a-completely-different-verifier"},
+	})
+
+	if w.Code != 400 {
+		t.Fatalf("expected 400 for a PKCE mismatch, got %d: %v", w.Code, body)
+	}
+	if body["error"] != string(errInvalidGrant) {
+		t.Errorf("expected error=invalid_grant, got %v", body["error"])
+	}
+}
+
+func TestGrantAuthorizationCodeWrongClient(t *testing.T) {
+	client := confidentialClient()
+	other := &Client{ID: "other-client", GrantTypes: []GrantType{GrantAuthorizationCode}}
+	srv, codes, _ := testServer(t, client, false)
+	srv.clients = fakeClientStore{client.ID: client, other.ID: other}
+
+	_ = codes.SaveCode(context.Background(), &AuthCode{
+		Code:        "auth-code-2",
+		ClientID:    other.ID, // issued to a different client than the one exchanging it
+		RedirectURI: client.RedirectURIs[0],
+	})
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"code":          {"auth-code-2"},
+		"redirect_uri":  {client.RedirectURIs[0]},
+	}
+	w, body := doToken(srv, form)
+
+	if w.Code != 400 {
+		t.Fatalf("expected 400 when the code was issued to a different client, got %d: %v", w.Code, body)
+	}
+	if body["error"] != string(errInvalidGrant) {
+		t.Errorf("expected error=invalid_grant, got %v", body["error"])
+	}
+}
+
+func TestGrantAuthorizationCodeReplay(t *testing.T) {
+	client := confidentialClient()
+	srv, codes, _ := testServer(t, client, false)
+
+	_ = codes.SaveCode(context.Background(), &AuthCode{
+		Code:        "auth-code-3",
+		ClientID:    client.ID,
+		RedirectURI: client.RedirectURIs[0],
+	})
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"code":          {"auth-code-3"},
+		"redirect_uri":  {client.RedirectURIs[0]},
+	}
+
+	w1, body1 := doToken(srv, form)
+	if w1.Code != 200 {
+		t.Fatalf("expected the first exchange to succeed, got %d: %v", w1.Code, body1)
+	}
+
+	w2, body2 := doToken(srv, form)
+	if w2.Code != 400 {
+		t.Fatalf("expected replaying the same code to fail, got %d: %v", w2.Code, body2)
+	}
+	if body2["error"] != string(errInvalidGrant) {
+		t.Errorf("expected error=invalid_grant on replay, got %v", body2["error"])
+	}
+}
+
+func TestGrantRefreshTokenRotationRevokesOldToken(t *testing.T) {
+	client := confidentialClient()
+	srv, codes, _ := testServer(t, client, false)
+
+	_ = codes.SaveCode(context.Background(), &AuthCode{
+		Code:        "auth-code-4",
+		ClientID:    client.ID,
+		RedirectURI: client.RedirectURIs[0],
+	})
+	_, body := doToken(srv, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"code":          {"auth-code-4"},
+		"redirect_uri":  {client.RedirectURIs[0]},
+	})
+	firstRefresh, _ := body["refresh_token"].(string)
+	if firstRefresh == "" {
+		t.Fatalf("expected a refresh_token from the initial grant, got %v", body)
+	}
+
+	refreshForm := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"refresh_token": {firstRefresh},
+	}
+
+	w1, body1 := doToken(srv, refreshForm)
+	if w1.Code != 200 {
+		t.Fatalf("expected the first refresh to succeed, got %d: %v", w1.Code, body1)
+	}
+
+	// The old refresh token was rotated out on the first use; presenting it
+	// again (e.g. a stolen, replayed token) must fail.
+	w2, body2 := doToken(srv, refreshForm)
+	if w2.Code != 400 {
+		t.Fatalf("expected reusing a rotated refresh token to fail, got %d: %v", w2.Code, body2)
+	}
+	if body2["error"] != string(errInvalidGrant) {
+		t.Errorf("expected error=invalid_grant on refresh reuse, got %v", body2["error"])
+	}
+}
+
+func TestGrantPasswordWrongPassword(t *testing.T) {
+	client := confidentialClient()
+	srv, _, _ := testServer(t, client, true)
+
+	w, body := doToken(srv, url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"username":      {"user@example.com"},
+		"password":      {"wrong-password"},
+	})
+
+	if w.Code != 400 {
+		t.Fatalf("expected 400 for a wrong password, got %d: %v", w.Code, body)
+	}
+	if body["error"] != string(errInvalidGrant) {
+		t.Errorf("expected error=invalid_grant, got %v", body["error"])
+	}
+}
+
+func TestGrantPasswordSuccess(t *testing.T) {
+	client := confidentialClient()
+	srv, _, _ := testServer(t, client, true)
+
+	w, body := doToken(srv, url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {client.ID},
+		"client_secret": {"client-secret"},
+		"username":      {"user@example.com"},
+		"password":      {"correct-password"},
+	})
+
+	if w.Code != 200 {
+		t.Fatalf("expected the password grant to succeed, got %d: %v", w.Code, body)
+	}
+	if body["access_token"] == "" || body["access_token"] == nil {
+		t.Errorf("expected an access_token in the response, got %v", body)
+	}
+}
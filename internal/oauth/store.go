@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package oauth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrClientNotFound = errors.New("oauth: client not found")
+	ErrCodeNotFound   = errors.New("oauth: authorization code not found or already used")
+	ErrTokenNotFound  = errors.New("oauth: token not found")
+)
+
+// ClientStore resolves registered OAuth2 clients by id.
+type ClientStore interface {
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+}
+
+// AuthCodeStore persists the short-lived codes minted by the authorize
+// endpoint. ConsumeCode must be atomic - a code is valid for exactly one
+// exchange, per RFC 6749 §4.1.2.
+type AuthCodeStore interface {
+	SaveCode(ctx context.Context, code *AuthCode) error
+	ConsumeCode(ctx context.Context, code string) (*AuthCode, error)
+}
+
+// TokenStore persists issued tokens, indexed by signature so lookups never
+// need the raw JWT. A signature is the lookup key derived from the token's
+// jti (see signature in server.go).
+type TokenStore interface {
+	SaveToken(ctx context.Context, token *Token) error
+	GetByAccessSignature(ctx context.Context, sig string) (*Token, error)
+	GetByRefreshSignature(ctx context.Context, sig string) (*Token, error)
+	Revoke(ctx context.Context, accessSignature string) error
+}
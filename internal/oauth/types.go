@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package oauth implements an RFC 6749 authorization server: the
+// authorization_code (with PKCE S256), client_credentials, refresh_token,
+// and password grants, plus RFC 7662 introspection and RFC 7009 revocation.
+// It's the provider side of the flow - internal/auth/connectors is the
+// client side, used to log into *other* providers like GitHub.
+package oauth
+
+import "time"
+
+// GrantType is one of the grant_type values a /oauth/token request can ask
+// for. Unlisted values are rejected as unsupported_grant_type.
+type GrantType string
+
+const (
+	GrantAuthorizationCode GrantType = "authorization_code"
+	GrantClientCredentials GrantType = "client_credentials"
+	GrantRefreshToken      GrantType = "refresh_token"
+	GrantPassword          GrantType = "password"
+)
+
+// Client is a registered OAuth2 client.
+type Client struct {
+	ID           string
+	SecretHash   string // empty for public clients (PKCE-only)
+	RedirectURIs []string
+	GrantTypes   []GrantType
+	Scopes       []string
+}
+
+// AllowsGrant reports whether the client is registered for grant.
+func (c *Client) AllowsGrant(grant GrantType) bool {
+	for _, g := range c.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRedirectURI reports whether uri is one of the client's registered
+// redirect URIs, compared exactly per RFC 6749 §3.1.2.3.
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// Public reports whether the client has no secret, i.e. it must use PKCE.
+func (c *Client) Public() bool {
+	return c.SecretHash == ""
+}
+
+// AuthCode is a short-lived authorization code minted by /oauth/authorize
+// and consumed exactly once by /oauth/token's authorization_code grant.
+type AuthCode struct {
+	Code                string
+	ClientID            string
+	UserID              int64
+	RedirectURI         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+}
+
+// Token is an issued access or refresh token, stored by its signature (not
+// the raw value) so a leaked database row can't be replayed as a bearer
+// token on its own.
+type Token struct {
+	AccessSignature  string
+	RefreshSignature string
+	ClientID         string
+	UserID           int64 // 0 for client_credentials tokens, which have no user
+	Scope            string
+	ExpiresAt        time.Time
+	RefreshExpiresAt time.Time
+	Revoked          bool
+}
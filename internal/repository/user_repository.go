@@ -22,6 +22,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"highperf-api/internal/database"
@@ -40,7 +41,126 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) (*models.User, error)
 	Delete(ctx context.Context, id int64) error
-	List(ctx context.Context, limit, offset int) ([]*models.User, int, error)
+
+	// List returns a page of users matching opts.Filters, ordered by
+	// opts.Sort, along with the total number of matching rows (ignoring
+	// Page/PageSize) so callers can build pagination headers.
+	List(ctx context.Context, opts ListOptions) ([]*models.User, int, error)
+
+	// UpsertFromIdentity links an external identity provider's (provider,
+	// subject) pair to a User, creating both the user and the link on first
+	// login and just returning the linked user on subsequent ones. Used by
+	// the OIDC/OAuth2 connector callbacks.
+	UpsertFromIdentity(ctx context.Context, provider, subject, email, name string) (*models.User, error)
+
+	// ListAfterID returns up to limit users with id > afterID, ordered by
+	// id ascending - a keyset cursor for handlers that stream the whole
+	// table (see httpserver.newStreamUsersHandler), so walking every row
+	// never needs more than one page in memory regardless of table size.
+	ListAfterID(ctx context.Context, afterID int64, limit int) ([]*models.User, error)
+}
+
+// listSortWhitelist maps the sort field names callers may request to the
+// actual column name, so a query parameter can never inject arbitrary SQL
+// into the ORDER BY clause.
+var listSortWhitelist = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// SortField is one {field, direction} pair of a List query's ORDER BY.
+// Field must be a key of listSortWhitelist; an unrecognized one is
+// silently dropped by ListOptions.orderBy rather than erroring, so a
+// malformed query parameter degrades to the default order instead of
+// failing the whole request.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListFilters narrows List to a subset of users. Zero values are ignored.
+type ListFilters struct {
+	EmailContains string
+	NameContains  string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// ListOptions controls List's filtering, sorting, and pagination.
+type ListOptions struct {
+	Filters  ListFilters
+	Sort     []SortField
+	Page     int
+	PageSize int
+}
+
+// whereClause builds the WHERE clause and its positional arguments for
+// opts.Filters, starting placeholders at argOffset+1.
+func (o ListOptions) whereClause(argOffset int) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", argOffset+len(args))
+	}
+
+	if o.Filters.EmailContains != "" {
+		clauses = append(clauses, "email ILIKE "+next("%"+o.Filters.EmailContains+"%"))
+	}
+	if o.Filters.NameContains != "" {
+		clauses = append(clauses, "name ILIKE "+next("%"+o.Filters.NameContains+"%"))
+	}
+	if !o.Filters.CreatedAfter.IsZero() {
+		clauses = append(clauses, "created_at >= "+next(o.Filters.CreatedAfter))
+	}
+	if !o.Filters.CreatedBefore.IsZero() {
+		clauses = append(clauses, "created_at <= "+next(o.Filters.CreatedBefore))
+	}
+
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+// orderBy builds the ORDER BY clause for opts.Sort, dropping any field not
+// in listSortWhitelist. Falls back to `created_at DESC` when Sort is empty
+// or every field was dropped.
+func (o ListOptions) orderBy() string {
+	var parts []string
+	for _, s := range o.Sort {
+		col, ok := listSortWhitelist[s.Field]
+		if !ok {
+			continue
+		}
+		if s.Desc {
+			parts = append(parts, col+" DESC")
+		} else {
+			parts = append(parts, col+" ASC")
+		}
+	}
+	if len(parts) == 0 {
+		return "created_at DESC"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// limitOffset returns the LIMIT/OFFSET values for opts.Page/PageSize,
+// defaulting to page 1 of 20 when unset.
+func (o ListOptions) limitOffset() (limit, offset int) {
+	pageSize := o.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	page := o.Page
+	if page <= 0 {
+		page = 1
+	}
+	return pageSize, (page - 1) * pageSize
 }
 
 type userRepository struct {
@@ -208,25 +328,24 @@ func (r *userRepository) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// List retrieves users with pagination
-func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int, error) {
-	// Get total count
-	var total int
-	countQuery := `SELECT COUNT(*) FROM users`
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
-	if err != nil {
-		r.logger.WithError(err).Error("failed to count users")
-		return nil, 0, fmt.Errorf("failed to count users: %w", err)
-	}
-
-	// Get users with pagination
-	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+// List retrieves a filtered, sorted page of users. The total row count
+// (ignoring Page/PageSize) rides along in the same query via
+// COUNT(*) OVER(), a single round trip instead of a separate COUNT(*)
+// query followed by the page query.
+func (r *userRepository) List(ctx context.Context, opts ListOptions) ([]*models.User, int, error) {
+	where, args := opts.whereClause(0)
+	limit, offset := opts.limitOffset()
+	args = append(args, limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT id, email, name, password_hash, created_at, updated_at, COUNT(*) OVER() AS total
 		FROM users
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`,
+		where, opts.orderBy(), len(args)-1, len(args))
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.WithError(err).Error("failed to list users")
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
@@ -234,6 +353,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 	defer rows.Close()
 
 	var users []*models.User
+	var total int
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(
@@ -243,6 +363,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 			&user.PasswordHash,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&total,
 		)
 		if err != nil {
 			r.logger.WithError(err).Error("failed to scan user row")
@@ -259,6 +380,108 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 	return users, total, nil
 }
 
+// UpsertFromIdentity links an external identity to a user, creating the
+// user on first login. The (provider, subject) pair lives in a separate
+// user_identities table since a single user can link several providers.
+func (r *userRepository) UpsertFromIdentity(ctx context.Context, provider, subject, email, name string) (*models.User, error) {
+	user := &models.User{}
+
+	err := r.db.WithTx(ctx, func(tx *sql.Tx) error {
+		linkQuery := `
+			SELECT u.id, u.email, u.name, u.password_hash, u.created_at, u.updated_at
+			FROM users u
+			JOIN user_identities ui ON ui.user_id = u.id
+			WHERE ui.provider = $1 AND ui.subject = $2`
+
+		err := tx.QueryRowContext(ctx, linkQuery, provider, subject).Scan(
+			&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to look up external identity: %w", err)
+		}
+
+		// No existing link: find or create the user by email, then link it.
+		// PasswordHash is left empty since the identity came in via the
+		// connector, not the password flow.
+		now := time.Now()
+		userQuery := `
+			INSERT INTO users (email, name, password_hash, created_at, updated_at)
+			VALUES ($1, $2, '', $3, $3)
+			ON CONFLICT (email) DO UPDATE SET updated_at = EXCLUDED.updated_at
+			RETURNING id, email, name, password_hash, created_at, updated_at`
+
+		if err := tx.QueryRowContext(ctx, userQuery, email, name, now).Scan(
+			&user.ID, &user.Email, &user.Name, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to upsert user from identity: %w", err)
+		}
+
+		linkInsert := `
+			INSERT INTO user_identities (user_id, provider, subject, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (provider, subject) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, linkInsert, user.ID, provider, subject, now); err != nil {
+			return fmt.Errorf("failed to link external identity: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.WithError(err).Error("failed to upsert user from identity",
+			"provider", provider,
+		)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListAfterID implements the keyset cursor described on UserRepository:
+// unlike List, it takes no filters or total count, so it never pays for
+// the COUNT(*) OVER() window function List needs for pagination headers.
+func (r *userRepository) ListAfterID(ctx context.Context, afterID int64, limit int) ([]*models.User, error) {
+	query := `
+		SELECT id, email, name, password_hash, created_at, updated_at
+		FROM users
+		WHERE id > $1
+		ORDER BY id ASC
+		LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("failed to list users after id", "after_id", afterID)
+		return nil, fmt.Errorf("failed to list users after id: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(
+			&user.ID,
+			&user.Email,
+			&user.Name,
+			&user.PasswordHash,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			r.logger.WithError(err).Error("failed to scan user row")
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithError(err).Error("error iterating user rows")
+		return nil, fmt.Errorf("error iterating user rows: %w", err)
+	}
+
+	return users, nil
+}
+
 // Helper function to check for unique constraint violations
 func isUniqueViolation(err error) bool {
 	// This is a simplified check - in production you'd want more robust error handling
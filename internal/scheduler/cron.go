@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/scheduler/cron.go
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed standard 5-field cron expression (minute hour
+// day-of-month month day-of-week), each field a set of the values it
+// matches. There's no vixie-cron day-name/month-name support - Policies
+// are authored by operators who can write "1" instead of "Mon".
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]struct{}
+	// domStar/dowStar record a "*" day field: the two day fields must both
+	// match when either is "*", and either one is enough when neither is.
+	domStar, dowStar bool
+}
+
+var cronFieldRanges = [5][2]int{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 = Sunday
+}
+
+// parseCron parses a 5-field cron expression into a cronSchedule. Each
+// field accepts "*", a single value, a "lo-hi" range, a comma-separated
+// list of any of those, and a "/step" suffix on any of them.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("scheduler: cron expression must have 5 fields (minute hour dom month dow), got %d", len(fields))
+	}
+
+	parsed := make([]map[int]struct{}, 5)
+	for i, f := range fields {
+		set, err := parseCronField(f, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("scheduler: cron field %d (%q): %w", i, f, err)
+		}
+		parsed[i] = set
+	}
+
+	return &cronSchedule{
+		minute:  parsed[0],
+		hour:    parsed[1],
+		dom:     parsed[2],
+		month:   parsed[3],
+		dow:     parsed[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]struct{}, error) {
+	set := make(map[int]struct{})
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart := part
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+			// lo/hi already default to the field's full range
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid range start %q", bounds[0])
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range end %q", bounds[1])
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = n, n
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d, %d]", rangePart, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the first minute-aligned time strictly after after that
+// matches the schedule, scanning up to four years ahead before giving up -
+// long enough for any expression a real cron (or day-of-month 31 in a
+// 30-day month) could ask for, short enough to never spin forever on one
+// that can never match (e.g. "0 0 31 2 *").
+func (s *cronSchedule) Next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(4, 0, 0)
+
+	for t.Before(limit) {
+		if _, ok := s.month[int(t.Month())]; !ok {
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, 1, 0)
+			continue
+		}
+		_, domOK := s.dom[t.Day()]
+		_, dowOK := s.dow[int(t.Weekday())]
+		dayOK := domOK || dowOK
+		if s.domStar || s.dowStar {
+			dayOK = domOK && dowOK
+		}
+		if !dayOK {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+			continue
+		}
+		if _, ok := s.hour[t.Hour()]; !ok {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(time.Hour)
+			continue
+		}
+		if _, ok := s.minute[t.Minute()]; !ok {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+
+	return time.Time{}, false
+}
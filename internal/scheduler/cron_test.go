@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsWrongFieldCount(t *testing.T) {
+	if _, err := parseCron("* * * *"); err == nil {
+		t.Error("expected a 4-field expression to be rejected")
+	}
+}
+
+func TestParseCronRejectsOutOfRangeValue(t *testing.T) {
+	if _, err := parseCron("60 * * * *"); err == nil {
+		t.Error("expected minute 60 to be rejected")
+	}
+	if _, err := parseCron("* * 32 * *"); err == nil {
+		t.Error("expected day-of-month 32 to be rejected")
+	}
+}
+
+func TestCronScheduleNextEveryMinute(t *testing.T) {
+	s, err := parseCron("* * * * *")
+	if err != nil {
+		t.Fatalf("parseCron failed: %v", err)
+	}
+
+	after := time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC)
+	next, ok := s.Next(after)
+	if !ok {
+		t.Fatal("expected a next time to exist")
+	}
+	want := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("expected %v, got %v", want, next)
+	}
+}
+
+func TestCronScheduleNextSpecificHourAndMinute(t *testing.T) {
+	// "30 9 * * *": every day at 09:30.
+	s, err := parseCron("30 9 * * *")
+	if err != nil {
+		t.Fatalf("parseCron failed: %v", err)
+	}
+
+	after := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	next, ok := s.Next(after)
+	if !ok {
+		t.Fatal("expected a next time to exist")
+	}
+	want := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("expected %v, got %v", want, next)
+	}
+}
+
+func TestCronScheduleNextStep(t *testing.T) {
+	// "*/15 * * * *": every 15 minutes.
+	s, err := parseCron("*/15 * * * *")
+	if err != nil {
+		t.Fatalf("parseCron failed: %v", err)
+	}
+
+	after := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)
+	next, ok := s.Next(after)
+	if !ok {
+		t.Fatal("expected a next time to exist")
+	}
+	want := time.Date(2026, 3, 1, 12, 15, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("expected %v, got %v", want, next)
+	}
+}
+
+func TestCronScheduleNextUnsatisfiableGivesUp(t *testing.T) {
+	// Feb never has a 31st: Next should give up rather than loop forever.
+	s, err := parseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("parseCron failed: %v", err)
+	}
+
+	if _, ok := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("expected an unsatisfiable schedule to report no next time")
+	}
+}
+
+func TestCronScheduleNextDayOfWeekOrDayOfMonth(t *testing.T) {
+	// Per standard cron semantics, when both dom and dow are restricted
+	// (neither is "*"), a match on either one is enough.
+	s, err := parseCron("0 0 15 * 1")
+	if err != nil {
+		t.Fatalf("parseCron failed: %v", err)
+	}
+
+	// 2026-03-02 is a Monday, which should match via day-of-week even
+	// though it isn't the 15th.
+	next, ok := s.Next(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("expected a next time to exist")
+	}
+	if next.Weekday() != time.Monday && next.Day() != 15 {
+		t.Errorf("expected the next run to match day-of-week or day-of-month, got %v", next)
+	}
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/scheduler/postgres.go
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"highperf-api/internal/database"
+	"highperf-api/internal/logger"
+)
+
+// PostgresPolicyStore persists Policies in the `scheduler_policies` table.
+type PostgresPolicyStore struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresPolicyStore creates a PolicyStore backed by Postgres.
+func NewPostgresPolicyStore(db *database.DB, log *logger.Logger) *PostgresPolicyStore {
+	return &PostgresPolicyStore{db: db, logger: log}
+}
+
+func (s *PostgresPolicyStore) Create(ctx context.Context, p *Policy) (*Policy, error) {
+	query := `
+		INSERT INTO scheduler_policies
+			(name, enabled, cron_str, trigger_kind, description, job_kind, params, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at`
+
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	err := s.db.QueryRowContext(ctx, query,
+		p.Name, p.Enabled, p.CronExpr, p.Trigger, p.Description, p.JobKind, p.Params, p.CreatedAt, p.UpdatedAt,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to create scheduler policy", "name", p.Name)
+		return nil, fmt.Errorf("failed to create scheduler policy: %w", err)
+	}
+	return p, nil
+}
+
+func (s *PostgresPolicyStore) GetByID(ctx context.Context, id int64) (*Policy, error) {
+	query := `
+		SELECT id, name, enabled, cron_str, trigger_kind, description, job_kind, params, created_at, updated_at
+		FROM scheduler_policies
+		WHERE id = $1`
+
+	p := &Policy{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&p.ID, &p.Name, &p.Enabled, &p.CronExpr, &p.Trigger, &p.Description, &p.JobKind, &p.Params, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPolicyNotFound
+		}
+		s.logger.WithError(err).Error("failed to get scheduler policy", "policy_id", id)
+		return nil, fmt.Errorf("failed to get scheduler policy: %w", err)
+	}
+	return p, nil
+}
+
+func (s *PostgresPolicyStore) Update(ctx context.Context, p *Policy) (*Policy, error) {
+	query := `
+		UPDATE scheduler_policies
+		SET name = $2, cron_str = $3, trigger_kind = $4, description = $5, job_kind = $6, params = $7, updated_at = $8
+		WHERE id = $1
+		RETURNING updated_at`
+
+	p.UpdatedAt = time.Now()
+	err := s.db.QueryRowContext(ctx, query,
+		p.ID, p.Name, p.CronExpr, p.Trigger, p.Description, p.JobKind, p.Params, p.UpdatedAt,
+	).Scan(&p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPolicyNotFound
+		}
+		s.logger.WithError(err).Error("failed to update scheduler policy", "policy_id", p.ID)
+		return nil, fmt.Errorf("failed to update scheduler policy: %w", err)
+	}
+	return p, nil
+}
+
+func (s *PostgresPolicyStore) SetEnabled(ctx context.Context, id int64, enabled bool) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE scheduler_policies SET enabled = $2, updated_at = $3 WHERE id = $1`,
+		id, enabled, time.Now(),
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to toggle scheduler policy", "policy_id", id)
+		return fmt.Errorf("failed to toggle scheduler policy: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrPolicyNotFound
+	}
+	return nil
+}
+
+func (s *PostgresPolicyStore) List(ctx context.Context, opts ListOptions) ([]*Policy, int, error) {
+	var clauses []string
+	var args []interface{}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if opts.Filters.Enabled != nil {
+		clauses = append(clauses, "enabled = "+next(*opts.Filters.Enabled))
+	}
+	if opts.Filters.JobKind != "" {
+		clauses = append(clauses, "job_kind = "+next(opts.Filters.JobKind))
+	}
+	where := ""
+	if len(clauses) > 0 {
+		where = "WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	page := opts.Page
+	if page <= 0 {
+		page = 1
+	}
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	query := fmt.Sprintf(`
+		SELECT id, name, enabled, cron_str, trigger_kind, description, job_kind, params, created_at, updated_at, COUNT(*) OVER() AS total
+		FROM scheduler_policies
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		where, len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list scheduler policies")
+		return nil, 0, fmt.Errorf("failed to list scheduler policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*Policy
+	var total int
+	for rows.Next() {
+		p := &Policy{}
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Enabled, &p.CronExpr, &p.Trigger, &p.Description, &p.JobKind, &p.Params, &p.CreatedAt, &p.UpdatedAt, &total,
+		); err != nil {
+			s.logger.WithError(err).Error("failed to scan scheduler policy row")
+			return nil, 0, fmt.Errorf("failed to scan scheduler policy row: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating scheduler policy rows: %w", err)
+	}
+
+	return policies, total, nil
+}
+
+func (s *PostgresPolicyStore) ListEnabledScheduled(ctx context.Context) ([]*Policy, error) {
+	query := `
+		SELECT id, name, enabled, cron_str, trigger_kind, description, job_kind, params, created_at, updated_at
+		FROM scheduler_policies
+		WHERE enabled = true AND trigger_kind = $1`
+
+	rows, err := s.db.QueryContext(ctx, query, TriggerScheduled)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list enabled scheduled policies")
+		return nil, fmt.Errorf("failed to list enabled scheduled policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*Policy
+	for rows.Next() {
+		p := &Policy{}
+		if err := rows.Scan(
+			&p.ID, &p.Name, &p.Enabled, &p.CronExpr, &p.Trigger, &p.Description, &p.JobKind, &p.Params, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduler policy row: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// PostgresExecutionStore persists Executions in the
+// `scheduler_executions` table.
+type PostgresExecutionStore struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresExecutionStore creates an ExecutionStore backed by Postgres.
+func NewPostgresExecutionStore(db *database.DB, log *logger.Logger) *PostgresExecutionStore {
+	return &PostgresExecutionStore{db: db, logger: log}
+}
+
+func (s *PostgresExecutionStore) Create(ctx context.Context, e *Execution) (*Execution, error) {
+	query := `
+		INSERT INTO scheduler_executions (policy_id, status, started_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	if e.StartedAt.IsZero() {
+		e.StartedAt = time.Now()
+	}
+	err := s.db.QueryRowContext(ctx, query, e.PolicyID, e.Status, e.StartedAt).Scan(&e.ID)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to create scheduler execution", "policy_id", e.PolicyID)
+		return nil, fmt.Errorf("failed to create scheduler execution: %w", err)
+	}
+	return e, nil
+}
+
+func (s *PostgresExecutionStore) Update(ctx context.Context, e *Execution) error {
+	query := `
+		UPDATE scheduler_executions
+		SET status = $2, finished_at = $3, logs = $4, error = $5
+		WHERE id = $1`
+
+	_, err := s.db.ExecContext(ctx, query, e.ID, e.Status, nullTime(e.FinishedAt), e.Logs, e.Error)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to update scheduler execution", "execution_id", e.ID)
+		return fmt.Errorf("failed to update scheduler execution: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresExecutionStore) ListByPolicy(ctx context.Context, policyID int64, page, pageSize int) ([]*Execution, int, error) {
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	query := `
+		SELECT id, policy_id, status, started_at, finished_at, logs, error, COUNT(*) OVER() AS total
+		FROM scheduler_executions
+		WHERE policy_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.QueryContext(ctx, query, policyID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list scheduler executions", "policy_id", policyID)
+		return nil, 0, fmt.Errorf("failed to list scheduler executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*Execution
+	var total int
+	for rows.Next() {
+		e := &Execution{}
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.PolicyID, &e.Status, &e.StartedAt, &finishedAt, &e.Logs, &e.Error, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan scheduler execution row: %w", err)
+		}
+		if finishedAt.Valid {
+			e.FinishedAt = finishedAt.Time
+		}
+		executions = append(executions, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating scheduler execution rows: %w", err)
+	}
+
+	return executions, total, nil
+}
+
+func (s *PostgresExecutionStore) MarkOrphanedRunning(ctx context.Context) ([]*Execution, error) {
+	query := `
+		UPDATE scheduler_executions
+		SET status = $1, finished_at = $2, error = $3
+		WHERE status = $4
+		RETURNING id, policy_id, status, started_at, finished_at, logs, error`
+
+	rows, err := s.db.QueryContext(ctx, query,
+		ExecutionStopped, time.Now(), "stopped: found running at startup, previous instance did not shut down cleanly", ExecutionRunning,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark orphaned scheduler executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*Execution
+	for rows.Next() {
+		e := &Execution{}
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.PolicyID, &e.Status, &e.StartedAt, &finishedAt, &e.Logs, &e.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned scheduler execution row: %w", err)
+		}
+		if finishedAt.Valid {
+			e.FinishedAt = finishedAt.Time
+		}
+		executions = append(executions, e)
+	}
+	return executions, rows.Err()
+}
+
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// PostgresLocker implements DistributedLocker with PostgreSQL advisory
+// locks, scoped by Policy id: pg_try_advisory_lock/pg_advisory_unlock are
+// session-scoped, so the lock must be taken and released on the exact same
+// connection, which is why TryLock checks one out of the pool with
+// db.Conn and hands back an unlock that closes it.
+type PostgresLocker struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPostgresLocker creates a DistributedLocker backed by PostgreSQL
+// advisory locks.
+func NewPostgresLocker(db *database.DB, log *logger.Logger) *PostgresLocker {
+	return &PostgresLocker{db: db, logger: log}
+}
+
+func (l *PostgresLocker) TryLock(ctx context.Context, policyID int64) (bool, func(context.Context) error, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, nil, fmt.Errorf("scheduler: acquire connection for advisory lock: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, policyID).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return false, nil, fmt.Errorf("scheduler: pg_try_advisory_lock: %w", err)
+	}
+	if !locked {
+		_ = conn.Close()
+		return false, nil, nil
+	}
+
+	unlock := func(ctx context.Context) error {
+		defer conn.Close()
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, policyID)
+		if err != nil {
+			l.logger.WithError(err).Error("failed to release advisory lock", "policy_id", policyID)
+		}
+		return err
+	}
+	return true, unlock, nil
+}
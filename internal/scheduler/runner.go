@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/scheduler/runner.go
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// JobRunner executes one Policy.JobKind. Run should honor ctx
+// cancellation promptly: Scheduler.Stop cancels every running job's
+// context for a graceful shutdown and waits for Run to return before
+// marking its Execution stopped.
+type JobRunner interface {
+	Run(ctx context.Context, params json.RawMessage) (logs string, err error)
+}
+
+// JobRunnerFunc adapts a plain function to JobRunner.
+type JobRunnerFunc func(ctx context.Context, params json.RawMessage) (string, error)
+
+func (f JobRunnerFunc) Run(ctx context.Context, params json.RawMessage) (string, error) {
+	return f(ctx, params)
+}
+
+type registeredRunner struct {
+	runner JobRunner
+	// sem bounds how many Executions of this kind run at once: it's
+	// created with concurrencyLimit tokens and acquired for the lifetime
+	// of one Run call.
+	sem chan struct{}
+}
+
+// Registry maps a Policy's JobKind (e.g. "user.export", "db.vacuum",
+// "audit.verify") to the JobRunner that executes it and the maximum
+// number of that kind's Executions the Scheduler will run at once.
+type Registry struct {
+	mu      sync.RWMutex
+	runners map[string]registeredRunner
+}
+
+// NewRegistry creates an empty Registry. Register every JobKind the
+// Scheduler is expected to dispatch before calling Scheduler.Start - a
+// Policy whose JobKind has no registered runner is skipped with a logged
+// warning rather than failing the whole fire loop.
+func NewRegistry() *Registry {
+	return &Registry{runners: make(map[string]registeredRunner)}
+}
+
+// Register adds or replaces the runner for kind. concurrencyLimit caps how
+// many Executions of this kind the Scheduler runs concurrently across all
+// Policies that share it; a value <= 0 is treated as 1.
+func (reg *Registry) Register(kind string, runner JobRunner, concurrencyLimit int) {
+	if concurrencyLimit <= 0 {
+		concurrencyLimit = 1
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.runners[kind] = registeredRunner{runner: runner, sem: make(chan struct{}, concurrencyLimit)}
+}
+
+func (reg *Registry) lookup(kind string) (registeredRunner, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	rr, ok := reg.runners[kind]
+	return rr, ok
+}
+
+var errUnknownJobKind = fmt.Errorf("scheduler: no runner registered for job kind")
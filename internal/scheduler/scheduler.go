@@ -0,0 +1,356 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"highperf-api/internal/logger"
+)
+
+// fireEntry is one Policy's place in Scheduler's next-fire min-heap.
+type fireEntry struct {
+	policyID int64
+	schedule *cronSchedule
+	next     time.Time
+	index    int
+}
+
+type fireHeap []*fireEntry
+
+func (h fireHeap) Len() int            { return len(h) }
+func (h fireHeap) Less(i, j int) bool  { return h[i].next.Before(h[j].next) }
+func (h fireHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *fireHeap) Push(x interface{}) {
+	e := x.(*fireEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *fireHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
+
+// Scheduler parses Policy.CronExpr into fire times, holds them in a
+// min-heap, and dispatches due Policies to their JobRunner through a
+// per-kind bounded worker pool. A nil locker runs every due Policy
+// unconditionally, which is correct for a single-instance deployment;
+// pass a DistributedLocker (PostgresLocker) when more than one instance
+// shares the same Policy table.
+type Scheduler struct {
+	policies   PolicyStore
+	executions ExecutionStore
+	registry   *Registry
+	locker     DistributedLocker
+	logger     *logger.Logger
+
+	mu   sync.Mutex
+	heap fireHeap
+
+	runningMu sync.Mutex
+	running   map[int64]context.CancelFunc // executionID -> cancel
+	wg        sync.WaitGroup
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler creates a Scheduler. Call Start to begin firing Policies
+// and Stop to shut it down gracefully.
+func NewScheduler(policies PolicyStore, executions ExecutionStore, registry *Registry, locker DistributedLocker, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		policies:   policies,
+		executions: executions,
+		registry:   registry,
+		locker:     locker,
+		logger:     log,
+		running:    make(map[int64]context.CancelFunc),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start marks any Execution left ExecutionRunning by a prior crash as
+// ExecutionStopped, loads every enabled, scheduled Policy into the
+// fire-time heap, and begins the dispatch loop in the background.
+func (s *Scheduler) Start(ctx context.Context) error {
+	if orphans, err := s.executions.MarkOrphanedRunning(ctx); err != nil {
+		s.logger.WithError(err).Error("failed to mark orphaned executions stopped")
+	} else if len(orphans) > 0 {
+		s.logger.Info("marked orphaned executions stopped", "count", len(orphans))
+	}
+
+	policies, err := s.policies.ListEnabledScheduled(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, p := range policies {
+		s.scheduleLocked(p, now)
+	}
+	s.mu.Unlock()
+
+	go s.loop()
+	return nil
+}
+
+// Reschedule recomputes a Policy's place in the fire heap - call it after
+// creating, updating, enabling, or disabling a Policy so the dispatch
+// loop picks up the change without a restart.
+func (s *Scheduler) Reschedule(p *Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(p.ID)
+	if p.Enabled && p.Trigger == TriggerScheduled {
+		s.scheduleLocked(p, time.Now())
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) scheduleLocked(p *Policy, after time.Time) {
+	schedule, err := parseCron(p.CronExpr)
+	if err != nil {
+		s.logger.WithError(err).Error("invalid cron expression, policy will not fire", "policy_id", p.ID)
+		return
+	}
+	next, ok := schedule.Next(after)
+	if !ok {
+		s.logger.Error("cron expression never matches, policy will not fire", "policy_id", p.ID)
+		return
+	}
+	heap.Push(&s.heap, &fireEntry{policyID: p.ID, schedule: schedule, next: next})
+}
+
+func (s *Scheduler) removeLocked(policyID int64) {
+	for i, e := range s.heap {
+		if e.policyID == policyID {
+			heap.Remove(&s.heap, i)
+			return
+		}
+	}
+}
+
+// loop wakes whenever the earliest entry is due, dispatches it, and
+// reinserts it at its next fire time; it also wakes early on Reschedule
+// and on a ticker as a correctness backstop against clock jumps.
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		var wait time.Duration
+		if s.heap.Len() == 0 {
+			wait = time.Minute
+		} else {
+			wait = time.Until(s.heap[0].next)
+			if wait < 0 {
+				wait = 0
+			}
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-ticker.C:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		s.dispatchDue()
+	}
+}
+
+func (s *Scheduler) dispatchDue() {
+	now := time.Now()
+	var due []*fireEntry
+
+	s.mu.Lock()
+	for s.heap.Len() > 0 && !s.heap[0].next.After(now) {
+		e := heap.Pop(&s.heap).(*fireEntry)
+		due = append(due, e)
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		go s.fire(e.policyID, TriggerScheduled)
+
+		s.mu.Lock()
+		if next, ok := e.schedule.Next(now); ok {
+			heap.Push(&s.heap, &fireEntry{policyID: e.policyID, schedule: e.schedule, next: next})
+		}
+		s.mu.Unlock()
+	}
+}
+
+// TriggerNow fires policyID immediately, bypassing its cron schedule -
+// what the POST /policies/:id/trigger handler calls.
+func (s *Scheduler) TriggerNow(ctx context.Context, policyID int64) error {
+	return s.fireWithContext(ctx, policyID, TriggerManual)
+}
+
+func (s *Scheduler) fire(policyID int64, trigger TriggerKind) {
+	_ = s.fireWithContext(context.Background(), policyID, trigger)
+}
+
+// fireWithContext loads the Policy, acquires the distributed lock (if
+// any), records and runs the Execution, and releases the lock. It runs
+// synchronously with respect to its caller but the dispatch loop calls it
+// from its own goroutine per due Policy, so slow jobs don't delay other
+// Policies' fire times.
+func (s *Scheduler) fireWithContext(ctx context.Context, policyID int64, trigger TriggerKind) error {
+	p, err := s.policies.GetByID(ctx, policyID)
+	if err != nil {
+		return err
+	}
+
+	rr, ok := s.registry.lookup(p.JobKind)
+	if !ok {
+		s.logger.Error("no runner registered for job kind, skipping", "policy_id", policyID, "job_kind", p.JobKind)
+		return errUnknownJobKind
+	}
+
+	// Block for a free slot in this job kind's concurrency limit before
+	// doing anything else - taking the distributed lock or creating the
+	// Execution row first would let an unbounded number of Policies queue
+	// up holding locks/rows while waiting their turn to actually run.
+	select {
+	case rr.sem <- struct{}{}:
+		defer func() { <-rr.sem }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if s.locker != nil {
+		locked, unlock, err := s.locker.TryLock(ctx, policyID)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to acquire distributed lock", "policy_id", policyID)
+			return err
+		}
+		if !locked {
+			s.logger.Info("policy already running on another instance, skipping", "policy_id", policyID)
+			return nil
+		}
+		defer func() { _ = unlock(context.Background()) }()
+	}
+
+	execution := &Execution{PolicyID: policyID, Status: ExecutionRunning, StartedAt: time.Now()}
+	execution, err = s.executions.Create(ctx, execution)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s.runningMu.Lock()
+	s.running[execution.ID] = cancel
+	s.runningMu.Unlock()
+	s.wg.Add(1)
+
+	defer func() {
+		cancel()
+		s.runningMu.Lock()
+		delete(s.running, execution.ID)
+		s.runningMu.Unlock()
+		s.wg.Done()
+	}()
+
+	logs, runErr := rr.runner.Run(runCtx, p.Params)
+
+	execution.FinishedAt = time.Now()
+	execution.Logs = logs
+	switch {
+	case runCtx.Err() != nil:
+		// Canceled by Scheduler.Stop mid-run, not a job failure: record it
+		// as stopped so a restart's MarkOrphanedRunning sweep doesn't need
+		// to catch it, and so operators can tell "didn't finish" apart
+		// from "failed".
+		execution.Status = ExecutionStopped
+		execution.Error = "stopped: graceful shutdown"
+	case runErr != nil:
+		execution.Status = ExecutionFailed
+		execution.Error = runErr.Error()
+	default:
+		execution.Status = ExecutionSucceeded
+	}
+	if err := s.executions.Update(context.Background(), execution); err != nil {
+		s.logger.WithError(err).Error("failed to persist execution result", "execution_id", execution.ID)
+	}
+
+	s.logger.Info("execution finished", "execution_id", execution.ID, "policy_id", policyID, "trigger", trigger, "status", execution.Status)
+	return runErr
+}
+
+// Stop cancels every running job's context and waits (up to ctx's
+// deadline) for them to return; each canceled job's Execution is recorded
+// as ExecutionStopped once its Run returns. A row still "running" when the
+// process exits is marked stopped by MarkOrphanedRunning at the next
+// Start. Safe to call once; the dispatch loop exits as soon as Stop is
+// called even if jobs are still draining.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	<-s.done
+
+	s.runningMu.Lock()
+	remaining := make([]context.CancelFunc, 0, len(s.running))
+	for _, cancel := range s.running {
+		remaining = append(remaining, cancel)
+	}
+	s.runningMu.Unlock()
+	for _, cancel := range remaining {
+		cancel()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// internal/scheduler/store.go
+package scheduler
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrPolicyNotFound    = errors.New("scheduler: policy not found")
+	ErrExecutionNotFound = errors.New("scheduler: execution not found")
+)
+
+// PolicyFilters narrows PolicyStore.List. Zero values are ignored.
+type PolicyFilters struct {
+	Enabled *bool
+	JobKind string
+}
+
+// ListOptions controls PolicyStore.List's filtering and pagination.
+type ListOptions struct {
+	Filters  PolicyFilters
+	Page     int
+	PageSize int
+}
+
+// PolicyStore persists Policies and lists them for the /policies API.
+type PolicyStore interface {
+	Create(ctx context.Context, policy *Policy) (*Policy, error)
+	GetByID(ctx context.Context, id int64) (*Policy, error)
+	Update(ctx context.Context, policy *Policy) (*Policy, error)
+	SetEnabled(ctx context.Context, id int64, enabled bool) error
+	List(ctx context.Context, opts ListOptions) ([]*Policy, int, error)
+
+	// ListEnabledScheduled returns every enabled Policy whose Trigger is
+	// TriggerScheduled, for the Scheduler to load its fire-time heap from
+	// at startup; later changes reach the heap through Scheduler.Reschedule.
+	ListEnabledScheduled(ctx context.Context) ([]*Policy, error)
+}
+
+// ExecutionStore persists Executions and lists them for the
+// /policies/:id/executions API.
+type ExecutionStore interface {
+	Create(ctx context.Context, execution *Execution) (*Execution, error)
+	Update(ctx context.Context, execution *Execution) error
+	ListByPolicy(ctx context.Context, policyID int64, page, pageSize int) ([]*Execution, int, error)
+
+	// MarkOrphanedRunning finds Executions left in ExecutionRunning by an
+	// instance that crashed or was killed rather than shut down
+	// gracefully, and marks each one ExecutionStopped with an Error
+	// explaining why. The Scheduler calls this once at startup, before
+	// building its fire-time heap, so a restart never leaves a ghost
+	// execution stuck "running" forever.
+	MarkOrphanedRunning(ctx context.Context) ([]*Execution, error)
+}
+
+// DistributedLocker lets more than one Scheduler instance share a single
+// Policy table without two instances firing the same policy at once.
+// TryLock should be held for the duration of one Execution and released
+// via the returned unlock, even on failure paths.
+type DistributedLocker interface {
+	TryLock(ctx context.Context, policyID int64) (locked bool, unlock func(context.Context) error, err error)
+}
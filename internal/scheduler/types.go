@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2025 Miguel Mamani <miguel.coder.per@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as published
+ * by the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package scheduler runs recurring and on-demand jobs against cron-style
+// Policies, modeled loosely on Harbor's replication policies: a Policy
+// describes what to run and when, an Execution records one run of it. It
+// is deployment-agnostic about storage (PolicyStore/ExecutionStore) and
+// about what a job actually does (JobRunner), and supports more than one
+// process running the same Policy set via DistributedLocker.
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TriggerKind records how an Execution came to exist.
+type TriggerKind string
+
+const (
+	TriggerManual    TriggerKind = "manual"
+	TriggerScheduled TriggerKind = "scheduled"
+	TriggerEvent     TriggerKind = "event"
+)
+
+// Policy is one recurring (or manually triggered) job definition.
+type Policy struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Enabled     bool            `json:"enabled"`
+	CronExpr    string          `json:"cron_str"`
+	Trigger     TriggerKind     `json:"trigger_kind"`
+	Description string          `json:"description"`
+	JobKind     string          `json:"job_kind"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ExecutionStatus is where one Execution stands in its lifecycle.
+// Terminal states are ExecutionSucceeded, ExecutionFailed, and
+// ExecutionStopped; ExecutionPending and ExecutionRunning are the only
+// ones a later Update call is expected to move on from.
+type ExecutionStatus string
+
+const (
+	ExecutionPending   ExecutionStatus = "pending"
+	ExecutionRunning   ExecutionStatus = "running"
+	ExecutionSucceeded ExecutionStatus = "succeeded"
+	ExecutionFailed    ExecutionStatus = "failed"
+	ExecutionStopped   ExecutionStatus = "stopped"
+)
+
+// Execution is one run of a Policy.
+type Execution struct {
+	ID         int64           `json:"id"`
+	PolicyID   int64           `json:"policy_id"`
+	Status     ExecutionStatus `json:"status"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at,omitempty"`
+	Logs       string          `json:"logs,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}